@@ -0,0 +1,66 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// withToken requires requests to carry the token as a bearer token. Browsers sign in to the dashboard with the token
+// as the password of basic auth, which is only accepted under /ui where cross-origin requests are rejected. The
+// crontab triggers jobs through the API over loopback, so only that route is exempt for requests from the Machine
+// itself. When the token is empty, only requests from the Machine itself are served, as jobs, schedules and events
+// expose the Machine configs of schedules, env included.
+func withToken(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isLoopback(r) && r.Method == http.MethodPost && r.URL.Path == "/jobs/trigger" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if token == "" {
+				if isLoopback(r) {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				renderJSON(w, errRes{Error: "API_TOKEN must be set to use the API remotely"}, http.StatusForbidden)
+				return
+			}
+
+			dashboard := isDashboard(r)
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok && dashboard {
+				_, provided, ok = r.BasicAuth()
+			}
+
+			if !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+				if dashboard {
+					w.Header().Set("WWW-Authenticate", `Basic realm="cron-manager"`)
+				}
+				renderJSON(w, errRes{Error: "unauthorized"}, http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isLoopback(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// isDashboard reports whether the request is for the dashboard.
+func isDashboard(r *http.Request) bool {
+	return r.URL.Path == "/ui" || strings.HasPrefix(r.URL.Path, "/ui/")
+}
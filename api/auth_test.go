@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithToken(t *testing.T) {
+	handler := func(token string) http.Handler {
+		return withToken(token)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	tests := []struct {
+		name       string
+		token      string
+		method     string
+		path       string
+		remoteAddr string
+		bearer     string
+		status     int
+	}{
+		{name: "remote read without a token configured", method: http.MethodGet, path: "/jobs/1", remoteAddr: "10.0.0.2:1234", status: http.StatusForbidden},
+		{name: "remote change without a token configured", method: http.MethodPost, path: "/jobs/trigger", remoteAddr: "10.0.0.2:1234", status: http.StatusForbidden},
+		{name: "local read without a token configured", method: http.MethodGet, path: "/jobs/1", remoteAddr: "127.0.0.1:1234", status: http.StatusOK},
+		{name: "remote read without the token", token: "secret", method: http.MethodGet, path: "/jobs/1", remoteAddr: "10.0.0.2:1234", status: http.StatusUnauthorized},
+		{name: "remote read with the token", token: "secret", method: http.MethodGet, path: "/jobs/1", remoteAddr: "10.0.0.2:1234", bearer: "secret", status: http.StatusOK},
+		{name: "local trigger without the token", token: "secret", method: http.MethodPost, path: "/jobs/trigger", remoteAddr: "127.0.0.1:1234", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+
+			w := httptest.NewRecorder()
+			handler(tt.token).ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, w.Code)
+			}
+		})
+	}
+}
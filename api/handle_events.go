@@ -0,0 +1,128 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/sirupsen/logrus"
+)
+
+const eventsKeepAlive = 15 * time.Second
+
+// handleEvents streams events as server-sent events. The stream can be limited to schedules with a comma separated
+// schedule_id, and resumed after a dropped connection from the Last-Event-ID header.
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+	events := ctx.Value(eventsKey).(*cron.EventBus)
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		renderErr(w, fmt.Errorf("streaming is not supported"))
+		return
+	}
+
+	var filter cron.EventFilter
+	if param := r.URL.Query().Get("schedule_id"); param != "" {
+		for _, v := range strings.Split(param, ",") {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				renderJSON(w, errRes{Error: fmt.Sprintf("invalid schedule id: %s", err)}, http.StatusBadRequest)
+				return
+			}
+			filter.ScheduleIDs = append(filter.ScheduleIDs, id)
+		}
+	}
+
+	var lastID int
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		var err error
+		if lastID, err = strconv.Atoi(header); err != nil {
+			renderJSON(w, errRes{Error: fmt.Sprintf("invalid Last-Event-ID: %s", err)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Subscribe before replaying missed events, so none are lost in between.
+	ch, unsubscribe := events.Subscribe(filter)
+	defer unsubscribe()
+
+	var missed []cron.Event
+	if lastID > 0 {
+		store, err := cron.NewStore(cron.DefaultStorePath)
+		if err != nil {
+			log.WithError(err).Error("failed to initialize sqlite")
+			renderErr(w, err)
+			return
+		}
+
+		missed, err = store.ListEventsAfter(ctx, lastID, -1)
+		_ = store.Close()
+		if err != nil {
+			log.WithError(err).Error("failed to list events")
+			renderErr(w, err)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for _, event := range missed {
+		if !filter.Matches(event) {
+			continue
+		}
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+		lastID = event.ID
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventsKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			// Skip events that were already replayed
+			if event.ID <= lastID {
+				continue
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+			lastID = event.ID
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event cron.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -1,21 +1,39 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/go-chi/chi"
 	"github.com/sirupsen/logrus"
 )
 
 type triggerJobRequest struct {
 	ID int `json:"id"`
+	// Scheduled is set when the job is triggered by its schedule, rather than on demand.
+	// Scheduled jobs are delayed by the schedule's jitter before their Machine is launched.
+	Scheduled bool `json:"scheduled"`
+}
+
+type cancelJobRequest struct {
+	Signal string `json:"signal"`
+	// Timeout is the grace period, in seconds, given to the job before its Machine is destroyed.
+	Timeout int `json:"timeout"`
 }
 
 func handleJobTrigger(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	log := ctx.Value(loggerKey).(*logrus.Logger)
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
 
 	var req triggerJobRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -29,6 +47,18 @@ func handleJobTrigger(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
+	spread, err := cron.SpreadFromEnv()
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	limits, err := cron.ConcurrencyLimitsFromEnv()
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
 	store, err := cron.NewStore(cron.DefaultStorePath)
 	if err != nil {
 		log.WithError(err).Error("failed to initialize sqlite")
@@ -41,10 +71,287 @@ func handleJobTrigger(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	if err := cron.ProcessJob(ctx, log, store, req.ID); err != nil {
+	var job *cron.Job
+	if req.Scheduled {
+		// Keep the job going if cron stops waiting on the response.
+		job, err = cron.ProcessScheduledJob(context.WithoutCancel(ctx), log, store, runtimes, req.ID, spread, limits)
+	} else {
+		job, err = cron.ProcessJob(ctx, log, store, runtimes, req.ID, limits)
+	}
+	if err != nil {
 		log.WithError(err).Error("failed to process job")
 		renderErr(w, err)
 		return
 	}
 
+	renderJSON(w, Response{Result: job}, http.StatusCreated)
+}
+
+func handleJobCancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
+
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("invalid job id: %s", err)}, http.StatusBadRequest)
+		return
+	}
+
+	// The request body is optional, defaults are used when it's omitted.
+	var req cancelJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		log.WithError(err).Error("failed to decode job cancel request")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.WithError(err).Error("failed to close request body")
+		}
+	}()
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if err := cron.CancelJob(ctx, log, store, runtimes, jobID, req.Signal, timeout); err != nil {
+		log.WithError(err).Error("failed to cancel job")
+		renderErr(w, err)
+		return
+	}
+
+	job, err := store.FindJob(ctx, fmt.Sprint(jobID))
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: job}, http.StatusOK)
+}
+
+func handleJobRerun(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
+
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("invalid job id: %s", err)}, http.StatusBadRequest)
+		return
+	}
+
+	limits, err := cron.ConcurrencyLimitsFromEnv()
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	job, err := cron.RerunJob(ctx, log, store, runtimes, jobID, limits)
+	if err != nil {
+		log.WithError(err).Error("failed to rerun job")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: job}, http.StatusCreated)
+}
+
+type pruneJobsRequest struct {
+	DryRun bool `json:"dry_run"`
+}
+
+type jobDetails struct {
+	Job    *cron.Job       `json:"job"`
+	Events []cron.JobEvent `json:"events"`
+}
+
+func handleJobList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	filter, err := jobFilterFromQuery(r.URL.Query())
+	if err != nil {
+		renderJSON(w, errRes{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	jobs, err := store.ListJobsFiltered(ctx, filter)
+	if err != nil {
+		log.WithError(err).Error("failed to list jobs")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: jobs}, http.StatusOK)
+}
+
+// jobFilterFromQuery reads the filter of the job list from the query parameters.
+// Statuses are comma separated, and since and until are RFC3339 timestamps.
+func jobFilterFromQuery(query url.Values) (cron.JobFilter, error) {
+	filter := cron.JobFilter{
+		Sort:      cron.JobSortCreated,
+		Ascending: query.Get("ascending") == "true",
+	}
+
+	var err error
+	for _, param := range []struct {
+		name  string
+		value *int
+	}{
+		{"schedule_id", &filter.ScheduleID},
+		{"limit", &filter.Limit},
+		{"offset", &filter.Offset},
+	} {
+		if v := query.Get(param.name); v != "" {
+			if *param.value, err = strconv.Atoi(v); err != nil {
+				return filter, fmt.Errorf("invalid %s: %s", param.name, err)
+			}
+		}
+	}
+
+	for _, param := range []struct {
+		name  string
+		value *time.Time
+	}{
+		{"since", &filter.Since},
+		{"until", &filter.Until},
+	} {
+		if v := query.Get(param.name); v != "" {
+			if *param.value, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				return filter, fmt.Errorf("invalid %s: %s", param.name, err)
+			}
+		}
+	}
+
+	if v := query.Get("status"); v != "" {
+		filter.Statuses = strings.Split(v, ",")
+	}
+
+	if v := query.Get("sort"); v != "" {
+		filter.Sort = v
+	}
+
+	return filter, nil
+}
+
+func handleJobShow(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("invalid job id: %s", err)}, http.StatusBadRequest)
+		return
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	job, err := store.FindJob(ctx, strconv.Itoa(jobID))
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("failed to find job: %s", err)}, http.StatusNotFound)
+		return
+	}
+
+	events, err := store.ListJobEvents(ctx, job.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to list job events")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: jobDetails{Job: job, Events: events}}, http.StatusOK)
+}
+
+func handleJobPrune(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	// The request body is optional, jobs are pruned when it's omitted.
+	var req pruneJobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		log.WithError(err).Error("failed to decode job prune request")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.WithError(err).Error("failed to close request body")
+		}
+	}()
+
+	retention, err := cron.RetentionFromEnv()
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	results, err := cron.PruneJobs(ctx, store, log, retention, req.DryRun)
+	if err != nil {
+		log.WithError(err).Error("failed to prune jobs")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: results}, http.StatusOK)
 }
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/sirupsen/logrus"
+)
+
+// handleMetrics exposes the watchdog's alerts in the Prometheus text format.
+func handleMetrics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	schedules, err := store.ListSchedules(ctx)
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	names := make(map[int]string, len(schedules))
+	for _, schedule := range schedules {
+		names[schedule.ID] = schedule.Name
+	}
+
+	alerts, err := store.ListOpenScheduleAlerts(ctx)
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	counts, err := store.CountScheduleAlerts(ctx)
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	var b strings.Builder
+
+	b.WriteString("# HELP cron_manager_schedule_alert Open watchdog alerts by schedule and kind.\n")
+	b.WriteString("# TYPE cron_manager_schedule_alert gauge\n")
+	for _, alert := range alerts {
+		fmt.Fprintf(&b, "cron_manager_schedule_alert{schedule=%q,kind=%q} 1\n", names[alert.ScheduleID], alert.Kind)
+	}
+
+	kinds := make([]string, 0, len(counts))
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	b.WriteString("# HELP cron_manager_schedule_alerts_total Watchdog alerts raised by kind.\n")
+	b.WriteString("# TYPE cron_manager_schedule_alerts_total counter\n")
+	for _, kind := range kinds {
+		fmt.Fprintf(&b, "cron_manager_schedule_alerts_total{kind=%q} %d\n", kind, counts[kind])
+	}
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		log.WithError(err).Error("failed to write metrics")
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/sirupsen/logrus"
+)
+
+type pauseRequest struct {
+	ScheduleName string `json:"schedule_name"`
+	AppName      string `json:"app_name"`
+	Reason       string `json:"reason"`
+	// Until is when the pause expires, it lasts until it's resumed when omitted.
+	Until *time.Time `json:"until"`
+}
+
+type resumeRequest struct {
+	ScheduleName string `json:"schedule_name"`
+	AppName      string `json:"app_name"`
+}
+
+type resumeResponse struct {
+	Resumed int `json:"resumed"`
+}
+
+func handlePauseList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	pauses, err := store.ListPauses(ctx, time.Now())
+	if err != nil {
+		log.WithError(err).Error("failed to list pauses")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: pauses}, http.StatusOK)
+}
+
+func handlePauseCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	// The request body is optional, every schedule is paused when it's omitted.
+	var req pauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		log.WithError(err).Error("failed to decode pause request")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.WithError(err).Error("failed to close request body")
+		}
+	}()
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	pause := cron.Pause{
+		ScheduleName: req.ScheduleName,
+		AppName:      req.AppName,
+		Reason:       req.Reason,
+	}
+	if req.Until != nil {
+		pause.Until = sql.NullTime{Time: *req.Until, Valid: true}
+	}
+
+	created, err := cron.PauseSchedules(ctx, store, pause)
+	if err != nil {
+		renderJSON(w, errRes{Error: err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	renderJSON(w, Response{Result: created}, http.StatusCreated)
+}
+
+func handlePauseResume(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	// The request body is optional, the pause of every schedule is resumed when it's omitted.
+	var req resumeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		log.WithError(err).Error("failed to decode resume request")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := r.Body.Close(); err != nil {
+			log.WithError(err).Error("failed to close request body")
+		}
+	}()
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	n, err := store.DeletePauses(ctx, req.ScheduleName, req.AppName)
+	if err != nil {
+		log.WithError(err).Error("failed to resume")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: resumeResponse{Resumed: n}}, http.StatusOK)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+func handleScheduleStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	scheduleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("invalid schedule id: %s", err)}, http.StatusBadRequest)
+		return
+	}
+
+	window := cron.DefaultStatsWindow
+	if param := r.URL.Query().Get("window"); param != "" {
+		window, err = cron.ParseDuration(param)
+		if err != nil {
+			renderJSON(w, errRes{Error: fmt.Sprintf("invalid window: %s", err)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	schedule, err := store.FindSchedule(ctx, scheduleID)
+	if err != nil {
+		renderJSON(w, errRes{Error: fmt.Sprintf("failed to find schedule: %s", err)}, http.StatusNotFound)
+		return
+	}
+
+	stats, err := cron.ComputeScheduleStats(ctx, store, *schedule, window)
+	if err != nil {
+		log.WithError(err).Error("failed to compute schedule stats")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: stats}, http.StatusOK)
+}
+
+// defaultNextRuns is the number of fire times included with each schedule.
+const defaultNextRuns = 5
+
+// scheduleResponse is a schedule along with its next fire times. NextRunsError explains why the fire times
+// could not be computed, when the expression isn't supported.
+type scheduleResponse struct {
+	cron.Schedule
+	NextRuns      []time.Time `json:"next_runs"`
+	NextRunsError string      `json:"next_runs_error,omitempty"`
+}
+
+// handleScheduleList lists schedules, with the number of next fire times set with the next parameter.
+func handleScheduleList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	count := defaultNextRuns
+	if param := r.URL.Query().Get("next"); param != "" {
+		var err error
+		if count, err = strconv.Atoi(param); err != nil || count < 0 {
+			renderJSON(w, errRes{Error: fmt.Sprintf("invalid next: %q", param)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	schedules, err := store.ListSchedules(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to list schedules")
+		renderErr(w, err)
+		return
+	}
+
+	now := time.Now()
+	res := make([]scheduleResponse, 0, len(schedules))
+	for _, schedule := range schedules {
+		runs, err := cron.NextRuns(schedule.Schedule, now, count)
+
+		item := scheduleResponse{Schedule: schedule, NextRuns: runs}
+		if err != nil {
+			item.NextRunsError = err.Error()
+		}
+		res = append(res, item)
+	}
+
+	renderJSON(w, Response{Result: res}, http.StatusOK)
+}
+
+func handleScheduleSync(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	resolver, err := cron.NewImageResolverFromEnv()
+	if err != nil {
+		renderErr(w, err)
+		return
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
+
+	if err := cron.SyncSchedules(ctx, store, log, resolver, runtimes, cron.DefaultSchedulesFilePath); err != nil {
+		log.WithError(err).Error("failed to sync schedules")
+		renderErr(w, err)
+		return
+	}
+
+	if err := cron.SyncCrontab(ctx, store, log); err != nil {
+		log.WithError(err).Error("failed to sync crontab")
+		renderErr(w, err)
+		return
+	}
+
+	renderJSON(w, Response{Result: "Crontab synced successfully"}, http.StatusOK)
+}
+
+// handleScheduleStatsList computes the stats of every schedule.
+func handleScheduleStatsList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	window := cron.DefaultStatsWindow
+	if param := r.URL.Query().Get("window"); param != "" {
+		var err error
+		window, err = cron.ParseDuration(param)
+		if err != nil {
+			renderJSON(w, errRes{Error: fmt.Sprintf("invalid window: %s", err)}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderErr(w, err)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	schedules, err := store.ListSchedules(ctx)
+	if err != nil {
+		log.WithError(err).Error("failed to list schedules")
+		renderErr(w, err)
+		return
+	}
+
+	stats := make([]*cron.ScheduleStats, 0, len(schedules))
+	for _, schedule := range schedules {
+		s, err := cron.ComputeScheduleStats(ctx, store, schedule, window)
+		if err != nil {
+			log.WithError(err).Error("failed to compute schedule stats")
+			renderErr(w, err)
+			return
+		}
+		stats = append(stats, s)
+	}
+
+	renderJSON(w, Response{Result: stats}, http.StatusOK)
+}
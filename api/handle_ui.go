@@ -0,0 +1,395 @@
+package api
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/go-chi/chi"
+	"github.com/sirupsen/logrus"
+)
+
+// uiJobHistory is the number of jobs shown on a schedule's page.
+const uiJobHistory = 50
+
+//go:embed ui/*.html
+var uiFS embed.FS
+
+var uiFuncs = template.FuncMap{
+	"timestamp": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.UTC().Format("2006-01-02 15:04:05 UTC")
+	},
+	"duration": jobDuration,
+	"seconds": func(d time.Duration) string {
+		return d.Round(time.Millisecond).String()
+	},
+	"sparkline": sparkline,
+	"actions":   uiActionsEnabled,
+}
+
+// uiTemplates holds a template for each page, each sharing the layout.
+var uiTemplates = func() map[string]*template.Template {
+	pages := []string{"schedules.html", "schedule.html", "job.html", "error.html"}
+
+	templates := make(map[string]*template.Template, len(pages))
+	for _, page := range pages {
+		templates[page] = template.Must(template.New("layout.html").Funcs(uiFuncs).ParseFS(uiFS, "ui/layout.html", "ui/"+page))
+	}
+	return templates
+}()
+
+type scheduleRow struct {
+	Schedule cron.Schedule
+	NextRun  time.Time
+	LastJob  *cron.Job
+}
+
+type schedulePage struct {
+	Schedule cron.Schedule
+	NextRun  time.Time
+	Jobs     []cron.Job
+}
+
+type jobPage struct {
+	Job      cron.Job
+	Schedule cron.Schedule
+	Events   []cron.JobEvent
+	Timing   cron.JobTiming
+}
+
+func renderPage(w http.ResponseWriter, log *logrus.Logger, page string, data any, status int) {
+	// Render to a buffer first, so a failing template doesn't leave a partial page
+	var buf bytes.Buffer
+	if err := uiTemplates[page].Execute(&buf, data); err != nil {
+		log.WithError(err).Errorf("failed to render %s", page)
+		http.Error(w, "failed to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = buf.WriteTo(w)
+}
+
+func renderPageErr(w http.ResponseWriter, log *logrus.Logger, err error, status int) {
+	renderPage(w, log, "error.html", err.Error(), status)
+}
+
+// withStore opens a store for the page, rendering an error page if it can't be opened.
+func withStore(w http.ResponseWriter, log *logrus.Logger, page func(store *cron.Store)) {
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		log.WithError(err).Error("failed to initialize sqlite")
+		renderPageErr(w, log, err, http.StatusInternalServerError)
+		return
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			log.WithError(err).Error("failed to close store")
+		}
+	}()
+
+	page(store)
+}
+
+func handleUISchedules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	withStore(w, log, func(store *cron.Store) {
+		schedules, err := store.ListSchedules(ctx)
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusInternalServerError)
+			return
+		}
+
+		rows := make([]scheduleRow, 0, len(schedules))
+		for _, schedule := range schedules {
+			jobs, err := store.ListJobsFiltered(ctx, cron.JobFilter{ScheduleID: schedule.ID, Limit: 1, Sort: cron.JobSortCreated})
+			if err != nil {
+				renderPageErr(w, log, err, http.StatusInternalServerError)
+				return
+			}
+
+			row := scheduleRow{Schedule: schedule, NextRun: nextRun(schedule)}
+			if len(jobs) > 0 {
+				row.LastJob = &jobs[0]
+			}
+			rows = append(rows, row)
+		}
+
+		renderPage(w, log, "schedules.html", rows, http.StatusOK)
+	})
+}
+
+func handleUISchedule(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	scheduleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderPageErr(w, log, fmt.Errorf("invalid schedule id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	withStore(w, log, func(store *cron.Store) {
+		schedule, err := store.FindSchedule(ctx, scheduleID)
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusNotFound)
+			return
+		}
+
+		jobs, err := store.ListJobsFiltered(ctx, cron.JobFilter{ScheduleID: schedule.ID, Limit: uiJobHistory, Sort: cron.JobSortCreated})
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusInternalServerError)
+			return
+		}
+
+		renderPage(w, log, "schedule.html", schedulePage{Schedule: *schedule, NextRun: nextRun(*schedule), Jobs: jobs}, http.StatusOK)
+	})
+}
+
+func handleUIJob(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderPageErr(w, log, fmt.Errorf("invalid job id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	withStore(w, log, func(store *cron.Store) {
+		job, err := store.FindJob(ctx, strconv.Itoa(jobID))
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusNotFound)
+			return
+		}
+
+		schedule, err := store.FindSchedule(ctx, job.ScheduleID)
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusNotFound)
+			return
+		}
+
+		events, err := store.ListJobEvents(ctx, job.ID)
+		if err != nil {
+			renderPageErr(w, log, err, http.StatusInternalServerError)
+			return
+		}
+
+		renderPage(w, log, "job.html", jobPage{
+			Job:      *job,
+			Schedule: *schedule,
+			Events:   events,
+			Timing:   cron.CalculateJobTiming(*job, events),
+		}, http.StatusOK)
+	})
+}
+
+func handleUITrigger(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
+
+	scheduleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderPageErr(w, log, fmt.Errorf("invalid schedule id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	limits, err := cron.ConcurrencyLimitsFromEnv()
+	if err != nil {
+		renderPageErr(w, log, err, http.StatusInternalServerError)
+		return
+	}
+
+	withStore(w, log, func(store *cron.Store) {
+		job, err := cron.ProcessJob(ctx, log, store, runtimes, scheduleID, limits)
+		if err != nil {
+			log.WithError(err).Error("failed to process job")
+			renderPageErr(w, log, err, http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/ui/jobs/%d", job.ID), http.StatusSeeOther)
+	})
+}
+
+func handleUICancel(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	log := ctx.Value(loggerKey).(*logrus.Logger)
+	runtimes := ctx.Value(runtimesKey).(cron.RuntimeProvider)
+
+	jobID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		renderPageErr(w, log, fmt.Errorf("invalid job id: %w", err), http.StatusBadRequest)
+		return
+	}
+
+	withStore(w, log, func(store *cron.Store) {
+		// The schedule's stop signal and kill timeout are used
+		if err := cron.CancelJob(ctx, log, store, runtimes, jobID, "", 0); err != nil {
+			log.WithError(err).Error("failed to cancel job")
+			renderPageErr(w, log, err, http.StatusInternalServerError)
+			return
+		}
+
+		http.Redirect(w, r, fmt.Sprintf("/ui/jobs/%d", jobID), http.StatusSeeOther)
+	})
+}
+
+// handleUISetEnabled returns a handler enabling or disabling a schedule, and updating the crontab to match.
+func handleUISetEnabled(enabled bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		log := ctx.Value(loggerKey).(*logrus.Logger)
+
+		scheduleID, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			renderPageErr(w, log, fmt.Errorf("invalid schedule id: %w", err), http.StatusBadRequest)
+			return
+		}
+
+		withStore(w, log, func(store *cron.Store) {
+			if _, err := store.FindSchedule(ctx, scheduleID); err != nil {
+				renderPageErr(w, log, err, http.StatusNotFound)
+				return
+			}
+
+			if err := store.SetScheduleEnabled(ctx, scheduleID, enabled); err != nil {
+				renderPageErr(w, log, err, http.StatusInternalServerError)
+				return
+			}
+
+			if err := cron.SyncCrontab(ctx, store, log); err != nil {
+				log.WithError(err).Error("failed to sync crontab")
+
+				// Keep the schedule in line with what cron will run
+				if err := store.SetScheduleEnabled(ctx, scheduleID, !enabled); err != nil {
+					log.WithError(err).Error("failed to restore schedule")
+				}
+
+				renderPageErr(w, log, err, http.StatusInternalServerError)
+				return
+			}
+
+			http.Redirect(w, r, "/ui", http.StatusSeeOther)
+		})
+	}
+}
+
+// sameOrigin rejects requests made from other sites, as browsers send the credentials of the dashboard along with them.
+func sameOrigin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			site := r.Header.Get("Sec-Fetch-Site")
+			origin, err := url.Parse(r.Header.Get("Origin"))
+
+			if (site != "" && site != "same-origin" && site != "none") || (err == nil && origin.Host != "" && origin.Host != r.Host) {
+				http.Error(w, "cross-origin requests are not allowed", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// uiActionsEnabled reports whether jobs can be triggered and cancelled, and schedules enabled and disabled, from the
+// dashboard. Without an API_TOKEN nobody signs in to the dashboard, so it's read only.
+func uiActionsEnabled() bool {
+	return os.Getenv("API_TOKEN") != ""
+}
+
+// withUIActions rejects the dashboard's actions when they're disabled.
+func withUIActions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost && !uiActionsEnabled() {
+			http.Error(w, "API_TOKEN must be set to make changes from the dashboard", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// nextRun is when the schedule is next expected to run, or the zero time if it's disabled or won't run.
+func nextRun(schedule cron.Schedule) time.Time {
+	if !schedule.Enabled {
+		return time.Time{}
+	}
+
+	expr, err := cron.ParseCronExpression(schedule.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+
+	return expr.Next(time.Now())
+}
+
+// jobDuration is the time from the job being created until it finished, or until now for unfinished jobs.
+func jobDuration(job cron.Job) time.Duration {
+	end := time.Now()
+	if job.FinishedAt.Valid {
+		end = job.FinishedAt.Time
+	}
+	return end.Sub(job.CreatedAt).Round(time.Millisecond)
+}
+
+// sparkline renders the durations of the finished jobs, given newest first, as an inline SVG, oldest on the left.
+// Jobs that did not complete are marked in red.
+func sparkline(jobs []cron.Job) template.HTML {
+	const width, height, pad = 240.0, 40.0, 3.0
+
+	var finished []cron.Job
+	for i := len(jobs) - 1; i >= 0; i-- {
+		if jobs[i].FinishedAt.Valid {
+			finished = append(finished, jobs[i])
+		}
+	}
+
+	if len(finished) < 2 {
+		return ""
+	}
+
+	var longest time.Duration
+	for _, job := range finished {
+		longest = max(longest, jobDuration(job))
+	}
+	if longest == 0 {
+		longest = 1
+	}
+
+	var points, markers strings.Builder
+	step := (width - 2*pad) / float64(len(finished)-1)
+	for i, job := range finished {
+		x := pad + float64(i)*step
+		y := height - pad - (height-2*pad)*float64(jobDuration(job))/float64(longest)
+		fmt.Fprintf(&points, "%.1f,%.1f ", x, y)
+
+		if job.Status != cron.JobStatusCompleted {
+			fmt.Fprintf(&markers, `<circle cx="%.1f" cy="%.1f" r="2.5" fill="#c0392b"/>`, x, y)
+		}
+	}
+
+	return template.HTML(fmt.Sprintf(
+		`<svg class="sparkline" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f" role="img" aria-label="Job durations, up to %s"><polyline points="%s" fill="none" stroke="#7c3aed" stroke-width="1.5"/>%s</svg>`,
+		width, height, width, height, longest.Round(time.Millisecond), strings.TrimSpace(points.String()), markers.String(),
+	))
+}
@@ -10,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/fly-apps/cron-manager/internal/cron"
 	"github.com/go-chi/chi"
 	"github.com/sirupsen/logrus"
 )
@@ -19,6 +20,8 @@ type contextKey int
 const (
 	Port                 = 5500
 	loggerKey contextKey = iota
+	runtimesKey
+	eventsKey
 )
 
 var shuttingDown bool
@@ -34,9 +37,31 @@ func shutdownInterceptor(next http.Handler) http.Handler {
 }
 
 func StartHttpServer(logger *logrus.Logger) error {
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		return err
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		return fmt.Errorf("failed to initialize sqlite: %w", err)
+	}
+	defer func() { _ = store.Close() }()
+
+	events := cron.NewEventBus(store, logger)
+
+	eventsCtx, stopEvents := context.WithCancel(context.Background())
+	defer stopEvents()
+
+	go func() {
+		if err := events.Run(eventsCtx); err != nil && err != context.Canceled {
+			logger.WithError(err).Error("event bus stopped")
+		}
+	}()
+
 	r := chi.NewMux()
 	r.Use(shutdownInterceptor)
-	r.Mount("/", Handler(logger))
+	r.Mount("/", Handler(logger, runtimes, events))
 
 	w := logger.Writer()
 	defer func() { _ = w.Close() }()
@@ -48,6 +73,9 @@ func StartHttpServer(logger *logrus.Logger) error {
 		ErrorLog:          log.New(w, "", 0),
 	}
 
+	// Event streams stay open until the bus stops, which would otherwise hold up the shutdown.
+	server.RegisterOnShutdown(stopEvents)
+
 	// Create a channel to listen for shutdown signals
 	stopChan := make(chan os.Signal, 1)
 	signal.Notify(stopChan, os.Interrupt, syscall.SIGTERM, syscall.SIGINT)
@@ -74,11 +102,43 @@ func StartHttpServer(logger *logrus.Logger) error {
 	return nil
 }
 
-func Handler(logger *logrus.Logger) http.Handler {
+func Handler(logger *logrus.Logger, runtimes cron.RuntimeProvider, events *cron.EventBus) http.Handler {
 	r := chi.NewRouter()
+	r.Use(withRuntimes(runtimes))
+	r.Use(withEvents(events))
+	r.Use(withToken(os.Getenv("API_TOKEN")))
 	r.Route("/jobs", func(r chi.Router) {
+		r.Get("/", WithLogging(handleJobList, logger))
+		r.Get("/{id}", WithLogging(handleJobShow, logger))
 		r.Post("/trigger", WithLogging(handleJobTrigger, logger))
+		r.Post("/prune", WithLogging(handleJobPrune, logger))
+		r.Post("/{id}/cancel", WithLogging(handleJobCancel, logger))
+		r.Post("/{id}/rerun", WithLogging(handleJobRerun, logger))
+	})
+	r.Route("/schedules", func(r chi.Router) {
+		r.Get("/", WithLogging(handleScheduleList, logger))
+		r.Post("/sync", WithLogging(handleScheduleSync, logger))
+		r.Get("/stats", WithLogging(handleScheduleStatsList, logger))
+		r.Get("/{id}/stats", WithLogging(handleScheduleStats, logger))
+	})
+	r.Route("/pauses", func(r chi.Router) {
+		r.Get("/", WithLogging(handlePauseList, logger))
+		r.Post("/", WithLogging(handlePauseCreate, logger))
+		r.Post("/resume", WithLogging(handlePauseResume, logger))
 	})
+	r.Route("/ui", func(r chi.Router) {
+		r.Use(sameOrigin)
+		r.Use(withUIActions)
+		r.Get("/", WithLogging(handleUISchedules, logger))
+		r.Get("/schedules/{id}", WithLogging(handleUISchedule, logger))
+		r.Post("/schedules/{id}/trigger", WithLogging(handleUITrigger, logger))
+		r.Post("/schedules/{id}/enable", WithLogging(handleUISetEnabled(true), logger))
+		r.Post("/schedules/{id}/disable", WithLogging(handleUISetEnabled(false), logger))
+		r.Get("/jobs/{id}", WithLogging(handleUIJob, logger))
+		r.Post("/jobs/{id}/cancel", WithLogging(handleUICancel, logger))
+	})
+	r.Get("/events", WithLogging(handleEvents, logger))
+	r.Get("/metrics", WithLogging(handleMetrics, logger))
 
 	return r
 }
@@ -89,3 +149,23 @@ func WithLogging(h http.HandlerFunc, logger *logrus.Logger) http.HandlerFunc {
 		h(w, r.WithContext(ctx))
 	}
 }
+
+// withRuntimes shares a single RuntimeProvider across requests so clients are reused.
+func withRuntimes(runtimes cron.RuntimeProvider) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), runtimesKey, runtimes)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// withEvents shares the event bus across requests.
+func withEvents(events *cron.EventBus) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), eventsKey, events)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
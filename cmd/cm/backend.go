@@ -0,0 +1,492 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/spf13/cobra"
+)
+
+const apiTimeout = 5 * time.Minute
+
+// backend is what the commands operate on. It's implemented by the local store, and by the HTTP API
+// when --api-url is set, so commands behave and render identically whether cm runs locally or remotely.
+type backend interface {
+	ListSchedules(ctx context.Context) ([]cron.Schedule, error)
+	FindScheduleByName(ctx context.Context, name string) (*cron.Schedule, error)
+	// NextRuns returns the next fire times of every schedule by schedule ID, computed by the cron manager in the
+	// time zone cron runs in.
+	NextRuns(ctx context.Context, count int) (map[int]nextRuns, error)
+	SyncSchedules(ctx context.Context) error
+	// ScheduleStats computes the stats of the schedule, or of every schedule when scheduleID is 0.
+	ScheduleStats(ctx context.Context, scheduleID int, window time.Duration) ([]cron.ScheduleStats, error)
+
+	ListJobs(ctx context.Context, filter cron.JobFilter) ([]cron.Job, error)
+	FindJob(ctx context.Context, jobID int) (*cron.Job, []cron.JobEvent, error)
+	TriggerJob(ctx context.Context, scheduleID int) (*cron.Job, error)
+	CancelJob(ctx context.Context, jobID int, signal string, timeout time.Duration) (*cron.Job, error)
+	RerunJob(ctx context.Context, jobID int) (*cron.Job, error)
+	PruneJobs(ctx context.Context, dryRun bool) ([]cron.PruneResult, error)
+
+	ListPauses(ctx context.Context) ([]cron.Pause, error)
+	Pause(ctx context.Context, pause cron.Pause) (*cron.Pause, error)
+	// Resume deletes the pauses of exactly the schedule or app, or of every schedule when neither is set.
+	Resume(ctx context.Context, scheduleName, appName string) (int, error)
+}
+
+// nextRuns holds the next fire times of a schedule, or why they can't be computed.
+type nextRuns struct {
+	Runs []time.Time
+	Err  error
+}
+
+// newBackend returns the API backend when --api-url is set, and the local store otherwise.
+func newBackend(cmd *cobra.Command) (backend, error) {
+	apiURL, err := cmd.Flags().GetString("api-url")
+	if err != nil {
+		return nil, err
+	}
+
+	if apiURL != "" {
+		token, err := cmd.Flags().GetString("api-token")
+		if err != nil {
+			return nil, err
+		}
+
+		return newRemoteBackend(apiURL, token), nil
+	}
+
+	store, err := cron.NewStore(cron.DefaultStorePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create store: %w", err)
+	}
+
+	return &localBackend{store: store}, nil
+}
+
+// localBackend operates on the store directly, launching jobs with the configured execution backend.
+type localBackend struct {
+	store *cron.Store
+}
+
+func (b *localBackend) ListSchedules(ctx context.Context) ([]cron.Schedule, error) {
+	return b.store.ListSchedules(ctx)
+}
+
+func (b *localBackend) NextRuns(ctx context.Context, count int) (map[int]nextRuns, error) {
+	schedules, err := b.store.ListSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	runs := make(map[int]nextRuns, len(schedules))
+	for _, schedule := range schedules {
+		r, err := cron.NextRuns(schedule.Schedule, now, count)
+		runs[schedule.ID] = nextRuns{Runs: r, Err: err}
+	}
+
+	return runs, nil
+}
+
+func (b *localBackend) FindScheduleByName(ctx context.Context, name string) (*cron.Schedule, error) {
+	return b.store.FindScheduleByName(ctx, name)
+}
+
+func (b *localBackend) SyncSchedules(ctx context.Context) error {
+	resolver, err := cron.NewImageResolverFromEnv()
+	if err != nil {
+		return err
+	}
+
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		return err
+	}
+
+	return cron.SyncSchedules(ctx, b.store, log, resolver, runtimes, cron.DefaultSchedulesFilePath)
+}
+
+func (b *localBackend) ScheduleStats(ctx context.Context, scheduleID int, window time.Duration) ([]cron.ScheduleStats, error) {
+	schedules, err := b.store.ListSchedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	if scheduleID != 0 {
+		schedule, err := b.store.FindSchedule(ctx, scheduleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find schedule: %w", err)
+		}
+		schedules = []cron.Schedule{*schedule}
+	}
+
+	stats := make([]cron.ScheduleStats, 0, len(schedules))
+	for _, schedule := range schedules {
+		s, err := cron.ComputeScheduleStats(ctx, b.store, schedule, window)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute stats for schedule %s: %w", schedule.Name, err)
+		}
+		stats = append(stats, *s)
+	}
+
+	return stats, nil
+}
+
+func (b *localBackend) ListJobs(ctx context.Context, filter cron.JobFilter) ([]cron.Job, error) {
+	return b.store.ListJobsFiltered(ctx, filter)
+}
+
+func (b *localBackend) FindJob(ctx context.Context, jobID int) (*cron.Job, []cron.JobEvent, error) {
+	job, err := b.store.FindJob(ctx, strconv.Itoa(jobID))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events, err := b.store.ListJobEvents(ctx, job.ID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list job events: %w", err)
+	}
+
+	return job, events, nil
+}
+
+func (b *localBackend) TriggerJob(ctx context.Context, scheduleID int) (*cron.Job, error) {
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := cron.ConcurrencyLimitsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := cron.ProcessJob(ctx, log, b.store, runtimes, scheduleID, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.waitForLocalJob(ctx, runtimes, job)
+}
+
+func (b *localBackend) CancelJob(ctx context.Context, jobID int, signal string, timeout time.Duration) (*cron.Job, error) {
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cron.CancelJob(ctx, log, b.store, runtimes, jobID, signal, timeout); err != nil {
+		return nil, err
+	}
+
+	return b.store.FindJob(ctx, strconv.Itoa(jobID))
+}
+
+func (b *localBackend) RerunJob(ctx context.Context, jobID int) (*cron.Job, error) {
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := cron.ConcurrencyLimitsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := cron.RerunJob(ctx, log, b.store, runtimes, jobID, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	return b.waitForLocalJob(ctx, runtimes, job)
+}
+
+// waitForLocalJob keeps cm alive until local processes exit, as they are tied to it, returning the finished job.
+func (b *localBackend) waitForLocalJob(ctx context.Context, runtimes cron.RuntimeProvider, job *cron.Job) (*cron.Job, error) {
+	local, ok := runtimes.(*cron.LocalRuntime)
+	if !ok {
+		return job, nil
+	}
+
+	local.Wait()
+
+	return b.store.FindJob(ctx, strconv.Itoa(job.ID))
+}
+
+func (b *localBackend) PruneJobs(ctx context.Context, dryRun bool) ([]cron.PruneResult, error) {
+	retention, err := cron.RetentionFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	return cron.PruneJobs(ctx, b.store, log, retention, dryRun)
+}
+
+func (b *localBackend) ListPauses(ctx context.Context) ([]cron.Pause, error) {
+	return b.store.ListPauses(ctx, time.Now())
+}
+
+func (b *localBackend) Pause(ctx context.Context, pause cron.Pause) (*cron.Pause, error) {
+	return cron.PauseSchedules(ctx, b.store, pause)
+}
+
+func (b *localBackend) Resume(ctx context.Context, scheduleName, appName string) (int, error) {
+	return b.store.DeletePauses(ctx, scheduleName, appName)
+}
+
+// remoteBackend operates on a cron manager through its HTTP API.
+type remoteBackend struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func newRemoteBackend(apiURL, token string) *remoteBackend {
+	return &remoteBackend{
+		url:    strings.TrimSuffix(apiURL, "/"),
+		token:  token,
+		client: &http.Client{Timeout: apiTimeout},
+	}
+}
+
+// do sends a request to the API, decoding the result of the response into out.
+func (b *remoteBackend) do(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, b.url+path, reader)
+	if err != nil {
+		return err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if b.token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach api: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var res struct {
+		Result json.RawMessage `json:"result"`
+		Error  string          `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return fmt.Errorf("failed to decode api response (%s): %w", resp.Status, err)
+	}
+
+	if res.Error != "" {
+		return fmt.Errorf("%s", res.Error)
+	}
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("api responded with %s", resp.Status)
+	}
+
+	if out == nil || len(res.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(res.Result, out)
+}
+
+func (b *remoteBackend) ListSchedules(ctx context.Context) ([]cron.Schedule, error) {
+	var schedules []cron.Schedule
+	if err := b.do(ctx, http.MethodGet, "/schedules", nil, &schedules); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func (b *remoteBackend) FindScheduleByName(ctx context.Context, name string) (*cron.Schedule, error) {
+	schedules, err := b.ListSchedules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schedule := range schedules {
+		if schedule.Name == name {
+			return &schedule, nil
+		}
+	}
+
+	return nil, fmt.Errorf("schedule %s not found", name)
+}
+
+func (b *remoteBackend) NextRuns(ctx context.Context, count int) (map[int]nextRuns, error) {
+	var schedules []struct {
+		ID            int         `json:"id"`
+		NextRuns      []time.Time `json:"next_runs"`
+		NextRunsError string      `json:"next_runs_error"`
+	}
+	if err := b.do(ctx, http.MethodGet, fmt.Sprintf("/schedules?next=%d", count), nil, &schedules); err != nil {
+		return nil, err
+	}
+
+	runs := make(map[int]nextRuns, len(schedules))
+	for _, schedule := range schedules {
+		r := nextRuns{Runs: schedule.NextRuns}
+		if schedule.NextRunsError != "" {
+			r.Err = errors.New(schedule.NextRunsError)
+		}
+		runs[schedule.ID] = r
+	}
+
+	return runs, nil
+}
+
+func (b *remoteBackend) SyncSchedules(ctx context.Context) error {
+	return b.do(ctx, http.MethodPost, "/schedules/sync", nil, nil)
+}
+
+func (b *remoteBackend) ScheduleStats(ctx context.Context, scheduleID int, window time.Duration) ([]cron.ScheduleStats, error) {
+	query := url.Values{"window": {window.String()}}.Encode()
+
+	if scheduleID != 0 {
+		var stats cron.ScheduleStats
+		if err := b.do(ctx, http.MethodGet, fmt.Sprintf("/schedules/%d/stats?%s", scheduleID, query), nil, &stats); err != nil {
+			return nil, err
+		}
+		return []cron.ScheduleStats{stats}, nil
+	}
+
+	var stats []cron.ScheduleStats
+	if err := b.do(ctx, http.MethodGet, "/schedules/stats?"+query, nil, &stats); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+func (b *remoteBackend) ListJobs(ctx context.Context, filter cron.JobFilter) ([]cron.Job, error) {
+	query := url.Values{}
+	if filter.ScheduleID != 0 {
+		query.Set("schedule_id", strconv.Itoa(filter.ScheduleID))
+	}
+	if len(filter.Statuses) > 0 {
+		query.Set("status", strings.Join(filter.Statuses, ","))
+	}
+	if !filter.Since.IsZero() {
+		query.Set("since", filter.Since.Format(time.RFC3339Nano))
+	}
+	if !filter.Until.IsZero() {
+		query.Set("until", filter.Until.Format(time.RFC3339Nano))
+	}
+	query.Set("limit", strconv.Itoa(filter.Limit))
+	query.Set("offset", strconv.Itoa(filter.Offset))
+	if filter.Sort != "" {
+		query.Set("sort", filter.Sort)
+	}
+	if filter.Ascending {
+		query.Set("ascending", "true")
+	}
+
+	var jobs []cron.Job
+	if err := b.do(ctx, http.MethodGet, "/jobs?"+query.Encode(), nil, &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (b *remoteBackend) FindJob(ctx context.Context, jobID int) (*cron.Job, []cron.JobEvent, error) {
+	var details struct {
+		Job    *cron.Job       `json:"job"`
+		Events []cron.JobEvent `json:"events"`
+	}
+	if err := b.do(ctx, http.MethodGet, fmt.Sprintf("/jobs/%d", jobID), nil, &details); err != nil {
+		return nil, nil, err
+	}
+	return details.Job, details.Events, nil
+}
+
+func (b *remoteBackend) TriggerJob(ctx context.Context, scheduleID int) (*cron.Job, error) {
+	var job cron.Job
+	if err := b.do(ctx, http.MethodPost, "/jobs/trigger", map[string]int{"id": scheduleID}, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *remoteBackend) CancelJob(ctx context.Context, jobID int, signal string, timeout time.Duration) (*cron.Job, error) {
+	body := map[string]any{"signal": signal, "timeout": int(timeout.Seconds())}
+
+	var job cron.Job
+	if err := b.do(ctx, http.MethodPost, fmt.Sprintf("/jobs/%d/cancel", jobID), body, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *remoteBackend) RerunJob(ctx context.Context, jobID int) (*cron.Job, error) {
+	var job cron.Job
+	if err := b.do(ctx, http.MethodPost, fmt.Sprintf("/jobs/%d/rerun", jobID), nil, &job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (b *remoteBackend) PruneJobs(ctx context.Context, dryRun bool) ([]cron.PruneResult, error) {
+	var results []cron.PruneResult
+	if err := b.do(ctx, http.MethodPost, "/jobs/prune", map[string]bool{"dry_run": dryRun}, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (b *remoteBackend) ListPauses(ctx context.Context) ([]cron.Pause, error) {
+	var pauses []cron.Pause
+	if err := b.do(ctx, http.MethodGet, "/pauses/", nil, &pauses); err != nil {
+		return nil, err
+	}
+	return pauses, nil
+}
+
+func (b *remoteBackend) Pause(ctx context.Context, pause cron.Pause) (*cron.Pause, error) {
+	req := map[string]any{
+		"schedule_name": pause.ScheduleName,
+		"app_name":      pause.AppName,
+		"reason":        pause.Reason,
+	}
+	if pause.Until.Valid {
+		req["until"] = pause.Until.Time
+	}
+
+	var created cron.Pause
+	if err := b.do(ctx, http.MethodPost, "/pauses/", req, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (b *remoteBackend) Resume(ctx context.Context, scheduleName, appName string) (int, error) {
+	var res struct {
+		Resumed int `json:"resumed"`
+	}
+	req := map[string]string{"schedule_name": scheduleName, "app_name": appName}
+	if err := b.do(ctx, http.MethodPost, "/pauses/resume", req, &res); err != nil {
+		return 0, err
+	}
+	return res.Resumed, nil
+}
@@ -1,10 +1,13 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/fly-apps/cron-manager/internal/cron"
 	"github.com/olekukonko/tablewriter"
@@ -20,15 +23,47 @@ func main() {
 	var jobsCmd = &cobra.Command{Use: "jobs"}
 	rootCmd.AddCommand(schedulesCmd)
 	rootCmd.AddCommand(jobsCmd)
+	rootCmd.AddCommand(pauseCmd)
+	rootCmd.AddCommand(resumeCmd)
+
+	rootCmd.PersistentFlags().StringP("output", "o", outputTable, "Output format, one of json, yaml, csv or table")
+	rootCmd.PersistentFlags().String("api-url", os.Getenv("CM_API_URL"), "URL of a cron manager's API to run against, rather than the local store")
+	rootCmd.PersistentFlags().String("api-token", os.Getenv("CM_API_TOKEN"), "Token to authenticate with the API")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		format, err := outputFormat(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Keep logs out of machine-readable output
+		if format != outputTable {
+			log.SetOutput(os.Stderr)
+		}
+
+		return nil
+	}
 
 	schedulesCmd.AddCommand(syncCrontabCmd)
 	schedulesCmd.AddCommand(listCmd)
+	schedulesCmd.AddCommand(scheduleStatsCmd)
+	schedulesCmd.AddCommand(nextRunsCmd)
 
 	jobsCmd.AddCommand(listJobsCmd)
 	jobsCmd.AddCommand(processJobCmd)
 	jobsCmd.AddCommand(showJobCmd)
+	jobsCmd.AddCommand(cancelJobCmd)
+	jobsCmd.AddCommand(rerunJobCmd)
+	jobsCmd.AddCommand(pruneJobsCmd)
+	jobsCmd.AddCommand(watchJobCmd)
+
+	pauseCmd.AddCommand(listPausesCmd)
 
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
+
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -39,6 +74,31 @@ var log = logrus.New()
 func init() {
 	log.SetOutput(os.Stdout)
 	log.SetLevel(logrus.InfoLevel)
+
+	cancelJobCmd.Flags().String("signal", "", "Signal sent to the job's machine (defaults to the schedule's stop_signal)")
+	processJobCmd.Flags().Bool("wait", false, "Follow the job until it finishes, exiting with its exit code")
+	pruneJobsCmd.Flags().Bool("dry-run", false, "Report the jobs that would be pruned without deleting them")
+	nextRunsCmd.Flags().Int("count", 10, "Number of fire times to show")
+	scheduleStatsCmd.Flags().String("window", "7d", "Period to compute the statistics over, e.g. 24h or 30d")
+
+	listJobsCmd.Flags().Bool("all-schedules", false, "List jobs of every schedule")
+	listJobsCmd.Flags().String("schedule", "", "Name of the schedule to list jobs for")
+	listJobsCmd.Flags().StringSlice("status", nil, "Only list jobs with one of the statuses, e.g. failed,timed_out")
+	listJobsCmd.Flags().String("since", "", "Only list jobs created since a timestamp, date or duration ago, e.g. 24h")
+	listJobsCmd.Flags().String("until", "", "Only list jobs created until a timestamp, date or duration ago")
+	listJobsCmd.Flags().Int("limit", 10, "Maximum number of jobs to list, 0 for no limit")
+	listJobsCmd.Flags().Int("offset", 0, "Number of jobs to skip")
+	listJobsCmd.Flags().String("sort", cron.JobSortCreated, "Sort jobs by created or duration")
+	listJobsCmd.Flags().Bool("ascending", false, "Sort oldest or shortest first")
+
+	cancelJobCmd.Flags().Duration("timeout", 0, "Time to wait for the machine to stop before it is destroyed (defaults to the schedule's kill_timeout)")
+
+	pauseCmd.Flags().String("schedule", "", "Name of the schedule to pause")
+	pauseCmd.Flags().String("app", "", "Name of the app whose schedules are paused")
+	pauseCmd.Flags().String("until", "", "Timestamp or duration from now the pause expires at, e.g. 2h (defaults to until resumed)")
+	pauseCmd.Flags().String("reason", "", "Reason for the pause, recorded on the skipped jobs")
+	resumeCmd.Flags().String("schedule", "", "Name of the schedule to resume")
+	resumeCmd.Flags().String("app", "", "Name of the app whose schedules are resumed")
 }
 
 var listCmd = &cobra.Command{
@@ -48,44 +108,198 @@ var listCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		store, err := cron.NewStore(cron.DefaultStorePath)
+		b, err := newBackend(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to create store: %w", err)
+			return err
 		}
 
-		schedules, err := store.ListSchedules(cmd.Context())
+		schedules, err := b.ListSchedules(cmd.Context())
 		if err != nil {
 			return fmt.Errorf("failed to list schedules: %w", err)
 		}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"ID", "Target App", "Image", "Schedule", "Region", "Enabled", "Command"})
-
-		// Set table alignment, borders, padding, etc. as needed
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
-		table.SetBorder(true) // Set to false to hide borders
-		table.SetCenterSeparator("|")
-		table.SetColumnSeparator("|")
-		table.SetRowSeparator("-")
-		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-		table.SetHeaderLine(true) // Enable header line
-		table.SetAutoWrapText(false)
+		runs, err := b.NextRuns(cmd.Context(), 1)
+		if err != nil {
+			return fmt.Errorf("failed to get next runs: %w", err)
+		}
 
+		output := make(scheduleListOutput, 0, len(schedules))
 		for _, schedule := range schedules {
-			table.Append([]string{
-				strconv.Itoa(schedule.ID),
-				fmt.Sprint(schedule.AppName),
-				fmt.Sprint(schedule.Config.Image),
-				fmt.Sprint(schedule.Schedule),
-				fmt.Sprint(schedule.Region),
-				fmt.Sprint(schedule.Enabled),
-				fmt.Sprint(schedule.Command),
-			})
+			output = append(output, newScheduleOutput(schedule, runs[schedule.ID]))
 		}
 
-		table.Render()
+		return writeOutput(cmd, output, func() error {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"ID", "Target App", "Image", "Schedule", "Next Run", "Region", "Enabled", "Command"})
+
+			// Set table alignment, borders, padding, etc. as needed
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetBorder(true) // Set to false to hide borders
+			table.SetCenterSeparator("|")
+			table.SetColumnSeparator("|")
+			table.SetRowSeparator("-")
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderLine(true) // Enable header line
+			table.SetAutoWrapText(false)
+
+			for i, schedule := range schedules {
+				table.Append([]string{
+					strconv.Itoa(schedule.ID),
+					fmt.Sprint(schedule.AppName),
+					scheduleImage(schedule),
+					fmt.Sprint(schedule.Schedule),
+					formatTime((*time.Time)(output[i].NextRun)),
+					scheduleRegions(schedule),
+					fmt.Sprint(schedule.Enabled),
+					fmt.Sprint(schedule.Command),
+				})
+			}
 
-		return nil
+			table.Render()
+
+			return nil
+		})
+	},
+}
+
+var nextRunsCmd = &cobra.Command{
+	Use:   "next <schedule name>",
+	Short: "Shows when a schedule will next run",
+	Long:  `Shows the next fire times of a schedule, computed from its expression.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		count, err := cmd.Flags().GetInt("count")
+		if err != nil {
+			return err
+		}
+
+		if count < 1 {
+			return fmt.Errorf("count must be at least 1")
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		schedule, err := b.FindScheduleByName(cmd.Context(), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to find schedule: %w", err)
+		}
+
+		next, err := b.NextRuns(cmd.Context(), count)
+		if err != nil {
+			return fmt.Errorf("failed to get next runs: %w", err)
+		}
+
+		runs, err := next[schedule.ID].Runs, next[schedule.ID].Err
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		output := nextRunsOutput{
+			ScheduleID:   schedule.ID,
+			ScheduleName: schedule.Name,
+			Schedule:     schedule.Schedule,
+		}
+		for _, run := range runs {
+			output.NextRuns = append(output.NextRuns, timestamp(run))
+		}
+
+		return writeOutput(cmd, output, func() error {
+			if !schedule.Enabled {
+				fmt.Printf("Schedule %s is disabled, it will not run until it's enabled\n", schedule.Name)
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Next Run", "In"})
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAutoWrapText(false)
+
+			for _, run := range runs {
+				table.Append([]string{formatTime(&run), formatDuration(run.Sub(now).Round(time.Second))})
+			}
+
+			table.Render()
+
+			return nil
+		})
+	},
+}
+
+var scheduleStatsCmd = &cobra.Command{
+	Use:   "stats [schedule id]",
+	Short: "Shows run statistics for schedules",
+	Long:  `Shows the success rate, durations and failure streak of each schedule, or of the specified schedule, flagging SLO violations.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		flag, err := cmd.Flags().GetString("window")
+		if err != nil {
+			return err
+		}
+
+		window, err := cron.ParseDuration(flag)
+		if err != nil {
+			return fmt.Errorf("invalid window: %w", err)
+		}
+
+		var scheduleID int
+		if len(args) == 1 {
+			if scheduleID, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("failed to convert schedule ID to integer: %w", err)
+			}
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		stats, err := b.ScheduleStats(cmd.Context(), scheduleID, window)
+		if err != nil {
+			return err
+		}
+		output := make(scheduleStatsListOutput, 0, len(stats))
+		for _, s := range stats {
+			output = append(output, newScheduleStatsOutput(s))
+		}
+
+		return writeOutput(cmd, output, func() error {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"ID", "Schedule", "Jobs", "Success Rate", "P50", "P95", "Max", "Last Success", "Last Failure", "Failure Streak", "SLO Violations"})
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAutoWrapText(false)
+
+			for _, stats := range output {
+				successRate := ""
+				if stats.Jobs > 0 {
+					successRate = fmt.Sprintf("%.1f%%", stats.SuccessRate*100)
+				}
+
+				table.Append([]string{
+					strconv.Itoa(stats.ScheduleID),
+					stats.ScheduleName,
+					strconv.Itoa(stats.Jobs),
+					successRate,
+					formatSeconds(stats.P50Duration),
+					formatSeconds(stats.P95Duration),
+					formatSeconds(stats.MaxDuration),
+					formatTime((*time.Time)(stats.LastSuccessAt)),
+					formatTime((*time.Time)(stats.LastFailureAt)),
+					strconv.Itoa(stats.FailureStreak),
+					strings.Join(stats.SLOViolations, "\n"),
+				})
+			}
+
+			fmt.Printf("Statistics over the last %s\n", flag)
+			table.Render()
+
+			return nil
+		})
 	},
 }
 
@@ -101,147 +315,523 @@ var processJobCmd = &cobra.Command{
 			return fmt.Errorf("failed to convert schedule ID to integer: %w", err)
 		}
 
-		store, err := cron.NewStore(cron.DefaultStorePath)
+		b, err := newBackend(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to create store: %w", err)
+			return err
 		}
 
-		schedule, err := store.FindSchedule(cmd.Context(), scheduleID)
+		wait, err := cmd.Flags().GetBool("wait")
 		if err != nil {
-			return fmt.Errorf("failed to find schedule: %w", err)
+			return err
+		}
+
+		job, err := b.TriggerJob(cmd.Context(), scheduleID)
+		if err != nil {
+			return err
 		}
 
-		return cron.ProcessJob(cmd.Context(), log, store, schedule.ID)
+		if wait {
+			if format, _ := outputFormat(cmd); format == outputTable {
+				fmt.Printf("Job %d triggered for schedule %d\n", job.ID, scheduleID)
+			}
+			return watchJob(cmd, b, job.ID)
+		}
+
+		return writeOutput(cmd, newJobOutput(*job), func() error {
+			fmt.Printf("Job %d triggered for schedule %d\n", job.ID, scheduleID)
+			return nil
+		})
 	},
 }
 var listJobsCmd = &cobra.Command{
-	Use:   "list <schedule id>",
-	Short: "Lists all jobs for the specified schedule",
-	Long:  `Lists all jobs for the specified schedule`,
-	Args:  cobra.ExactArgs(1),
+	Use:   "list [schedule id]",
+	Short: "Lists jobs for the specified schedule",
+	Long:  `Lists jobs for the schedule specified by ID or --schedule, or for every schedule with --all-schedules. The 10 most recent jobs are listed by default.`,
+	Args:  cobra.MaximumNArgs(1),
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		scheduleID := args[0]
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
 
-		store, err := cron.NewStore(cron.DefaultStorePath)
+		filter, err := jobFilterFromFlags(cmd, b, args)
 		if err != nil {
-			return fmt.Errorf("failed to create store: %w", err)
+			return err
 		}
 
-		jobs, err := store.ListJobs(cmd.Context(), scheduleID, 10)
+		jobs, err := b.ListJobs(cmd.Context(), filter)
 		if err != nil {
 			return fmt.Errorf("failed to list jobs: %w", err)
 		}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"ID", "Machine ID", "Status", "Exit Code", "Created At", "Updated At", "Finished At"})
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
-		table.SetBorder(true)
-		table.SetCenterSeparator("|")
-		table.SetColumnSeparator("|")
-		table.SetRowSeparator("-")
-		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-		table.SetHeaderLine(true)
-		table.SetAutoWrapText(false)
-
-		for _, j := range jobs {
-			created := j.CreatedAt.Format("2006-01-02 15:04:05 UTC")
-			updated := j.UpdatedAt.Format("2006-01-02 15:04:05 UTC")
-			var finished string
-			if j.FinishedAt.Valid {
-				finished = j.FinishedAt.Time.Format("2006-01-02 15:04:05 UTC")
-			} else {
-				finished = ""
+		output := make(jobListOutput, 0, len(jobs))
+		for _, job := range jobs {
+			output = append(output, newJobOutput(job))
+		}
+
+		return writeOutput(cmd, output, func() error {
+			header := []string{"ID", "Machine ID", "Status", "Exit Code", "Failure Reason", "Created At", "Updated At", "Finished At"}
+			if filter.ScheduleID == 0 {
+				header = append([]string{"Schedule ID"}, header...)
 			}
 
-			table.Append([]string{
-				strconv.Itoa(j.ID),
-				j.MachineID.String,
-				fmt.Sprint(j.Status),
-				strconv.Itoa(int(j.ExitCode.Int64)),
-				created,
-				updated,
-				finished,
-			})
-		}
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader(header)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetBorder(true)
+			table.SetCenterSeparator("|")
+			table.SetColumnSeparator("|")
+			table.SetRowSeparator("-")
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderLine(true)
+			table.SetAutoWrapText(false)
+
+			for _, j := range jobs {
+				created := j.CreatedAt.Format("2006-01-02 15:04:05 UTC")
+				updated := j.UpdatedAt.Format("2006-01-02 15:04:05 UTC")
+				var finished string
+				if j.FinishedAt.Valid {
+					finished = j.FinishedAt.Time.Format("2006-01-02 15:04:05 UTC")
+				} else {
+					finished = ""
+				}
+
+				row := []string{
+					strconv.Itoa(j.ID),
+					j.MachineID.String,
+					fmt.Sprint(j.Status),
+					strconv.Itoa(int(j.ExitCode.Int64)),
+					j.FailureReason.String,
+					created,
+					updated,
+					finished,
+				}
+				if filter.ScheduleID == 0 {
+					row = append([]string{strconv.Itoa(j.ScheduleID)}, row...)
+				}
+
+				table.Append(row)
+			}
 
-		table.Render()
+			table.Render()
 
-		return nil
+			return nil
+		})
 	},
 }
 
+// jobFilterFromFlags builds the filter for jobs list from its flags, resolving the schedule from its ID or name.
+func jobFilterFromFlags(cmd *cobra.Command, b backend, args []string) (cron.JobFilter, error) {
+	var filter cron.JobFilter
+
+	allSchedules, err := cmd.Flags().GetBool("all-schedules")
+	if err != nil {
+		return filter, err
+	}
+
+	scheduleName, err := cmd.Flags().GetString("schedule")
+	if err != nil {
+		return filter, err
+	}
+
+	switch {
+	case len(args) == 1:
+		if filter.ScheduleID, err = strconv.Atoi(args[0]); err != nil {
+			return filter, fmt.Errorf("failed to convert schedule ID to integer: %w", err)
+		}
+	case scheduleName != "":
+		schedule, err := b.FindScheduleByName(cmd.Context(), scheduleName)
+		if err != nil {
+			return filter, fmt.Errorf("failed to find schedule %s: %w", scheduleName, err)
+		}
+		filter.ScheduleID = schedule.ID
+	case !allSchedules:
+		return filter, fmt.Errorf("a schedule ID, --schedule or --all-schedules is required")
+	}
+
+	if filter.Statuses, err = cmd.Flags().GetStringSlice("status"); err != nil {
+		return filter, err
+	}
+
+	for _, status := range filter.Statuses {
+		switch status {
+		case cron.JobStatusPending, cron.JobStatusRunning, cron.JobStatusCompleted, cron.JobStatusFailed,
+			cron.JobStatusCancelled, cron.JobStatusTimedOut, cron.JobStatusLost, cron.JobStatusSkipped:
+		default:
+			return filter, fmt.Errorf("unknown job status %q", status)
+		}
+	}
+
+	for flag, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		value, err := cmd.Flags().GetString(flag)
+		if err != nil {
+			return filter, err
+		}
+
+		if value == "" {
+			continue
+		}
+
+		if *t, err = parseTimeFlag(value); err != nil {
+			return filter, fmt.Errorf("invalid --%s: %w", flag, err)
+		}
+	}
+
+	if filter.Limit, err = cmd.Flags().GetInt("limit"); err != nil {
+		return filter, err
+	}
+
+	if filter.Offset, err = cmd.Flags().GetInt("offset"); err != nil {
+		return filter, err
+	}
+
+	if filter.Sort, err = cmd.Flags().GetString("sort"); err != nil {
+		return filter, err
+	}
+
+	if filter.Sort != cron.JobSortCreated && filter.Sort != cron.JobSortDuration {
+		return filter, fmt.Errorf("unsupported sort %q, expected created or duration", filter.Sort)
+	}
+
+	if filter.Ascending, err = cmd.Flags().GetBool("ascending"); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseTimeFlag parses an RFC 3339 timestamp, a date, or a duration such as 24h or 7d ago.
+func parseTimeFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	d, err := cron.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a timestamp, date or duration, got %q", value)
+	}
+
+	return time.Now().Add(-d), nil
+}
+
 var showJobCmd = &cobra.Command{
 	Use:   "show <job id>",
 	Short: "Show job details",
 	Long:  `Show job details`,
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		jobID := args[0]
+		jobID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to convert job ID to integer: %w", err)
+		}
 
-		store, err := cron.NewStore(cron.DefaultStorePath)
+		b, err := newBackend(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to create store: %w", err)
+			return err
 		}
 
-		job, err := store.FindJob(cmd.Context(), jobID)
+		job, events, err := b.FindJob(cmd.Context(), jobID)
 		if err != nil {
 			return fmt.Errorf("failed to find job: %w", err)
 		}
 
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetBorder(false)
-		table.SetAutoWrapText(false)
-		table.SetColumnSeparator("=")
-		table.SetAlignment(tablewriter.ALIGN_LEFT)
-		table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
-
-		var finishedAt string
-		if job.FinishedAt.Valid {
-			finishedAt = job.FinishedAt.Time.Format("2006-01-02 15:04:05 UTC")
-		} else {
-			finishedAt = ""
-		}
-
-		rows := [][]string{
-			{
-				strconv.Itoa(job.ID),
-				job.Status,
-				job.MachineID.String,
-				strconv.Itoa(int(job.ExitCode.Int64)),
-				job.CreatedAt.Format("2006-01-02 15:04:05 UTC"),
-				job.UpdatedAt.Format("2006-01-02 15:04:05 UTC"),
-				finishedAt,
-				strings.Trim(job.Stdout.String, "\n"),
-				strings.Trim(job.Stderr.String, "\n"),
-			},
-		}
-
-		cols := []string{
-			"ID",
-			"Status",
-			"Machine ID",
-			"Exit Code",
-			"Created At",
-			"Updated At",
-			"Finished At",
-			"Stdout",
-			"Stderr",
-		}
-
-		fmt.Println("Job Details")
-
-		for _, row := range rows {
-			for i, col := range cols {
-				table.Append([]string{col, row[i]})
+		output, err := newJobDetailOutput(*job, events)
+		if err != nil {
+			return err
+		}
+
+		return writeOutput(cmd, output, func() error {
+			timing := cron.CalculateJobTiming(*job, events)
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetBorder(false)
+			table.SetAutoWrapText(false)
+			table.SetColumnSeparator("=")
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+			var finishedAt string
+			if job.FinishedAt.Valid {
+				finishedAt = job.FinishedAt.Time.Format("2006-01-02 15:04:05 UTC")
+			} else {
+				finishedAt = ""
 			}
-			table.Render()
+
+			rows := [][]string{
+				{
+					strconv.Itoa(job.ID),
+					job.Status,
+					job.MachineID.String,
+					job.MachineState.String,
+					job.Region.String,
+					job.ImageDigest.String,
+					formatRerunOf(job),
+					strconv.Itoa(int(job.ExitCode.Int64)),
+					job.FailureReason.String,
+					job.CreatedAt.Format("2006-01-02 15:04:05 UTC"),
+					job.UpdatedAt.Format("2006-01-02 15:04:05 UTC"),
+					finishedAt,
+					formatDuration(time.Duration(job.JitterDelay) * time.Second),
+					formatDuration(timing.Queue),
+					formatDuration(timing.Provision),
+					formatDuration(timing.Boot),
+					formatDuration(timing.Execution),
+					strings.Trim(job.Stdout.String, "\n"),
+					strings.Trim(job.Stderr.String, "\n"),
+				},
+			}
+
+			cols := []string{
+				"ID",
+				"Status",
+				"Machine ID",
+				"Machine State",
+				"Region",
+				"Image Digest",
+				"Rerun Of",
+				"Exit Code",
+				"Failure Reason",
+				"Created At",
+				"Updated At",
+				"Finished At",
+				"Jitter Delay",
+				"Queue Time",
+				"Provision Time",
+				"Boot Time",
+				"Execution Time",
+				"Stdout",
+				"Stderr",
+			}
+
+			fmt.Println("Job Details")
+
+			for _, row := range rows {
+				for i, col := range cols {
+					table.Append([]string{col, row[i]})
+				}
+				table.Render()
+			}
+
+			if len(job.LaunchAttempts) > 0 {
+				fmt.Println()
+				fmt.Println("Failed Launch Attempts")
+
+				attemptsTable := tablewriter.NewWriter(os.Stdout)
+				attemptsTable.SetHeader([]string{"Timestamp", "Region", "Error"})
+				attemptsTable.SetBorder(false)
+				attemptsTable.SetAutoWrapText(false)
+				attemptsTable.SetAlignment(tablewriter.ALIGN_LEFT)
+				attemptsTable.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+				for _, attempt := range job.LaunchAttempts {
+					attemptsTable.Append([]string{
+						attempt.Timestamp.UTC().Format("2006-01-02 15:04:05 UTC"),
+						attempt.Region,
+						attempt.Error,
+					})
+				}
+
+				attemptsTable.Render()
+			}
+
+			if job.ConfigSnapshot.Valid {
+				config, err := job.MachineConfig()
+				if err != nil {
+					return err
+				}
+
+				configBytes, err := json.MarshalIndent(config, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to format machine config: %w", err)
+				}
+
+				fmt.Println()
+				fmt.Println("Machine Config")
+				fmt.Println(string(configBytes))
+			}
+
+			if len(events) == 0 {
+				return nil
+			}
+
+			fmt.Println()
+			fmt.Println("Events")
+
+			eventsTable := tablewriter.NewWriter(os.Stdout)
+			eventsTable.SetHeader([]string{"Timestamp", "Type", "Status", "Source"})
+			eventsTable.SetBorder(false)
+			eventsTable.SetAutoWrapText(false)
+			eventsTable.SetAlignment(tablewriter.ALIGN_LEFT)
+			eventsTable.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+			for _, event := range events {
+				eventsTable.Append([]string{
+					event.Timestamp.UTC().Format("2006-01-02 15:04:05.000 UTC"),
+					event.Type,
+					event.Status,
+					event.Source,
+				})
+			}
+
+			eventsTable.Render()
+
+			return nil
+		})
+	},
+}
+
+var cancelJobCmd = &cobra.Command{
+	Use:   "cancel <job id>",
+	Short: "Cancels a pending or running job",
+	Long:  `Stops the job's machine with the specified signal, waits for the grace period and then destroys it.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to convert job ID to integer: %w", err)
 		}
 
-		return nil
+		signal, err := cmd.Flags().GetString("signal")
+		if err != nil {
+			return err
+		}
+
+		timeout, err := cmd.Flags().GetDuration("timeout")
+		if err != nil {
+			return err
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		job, err := b.CancelJob(cmd.Context(), jobID, signal, timeout)
+		if err != nil {
+			return fmt.Errorf("failed to cancel job: %w", err)
+		}
+
+		return writeOutput(cmd, newJobOutput(*job), func() error {
+			fmt.Printf("Job %d cancelled\n", jobID)
+			return nil
+		})
+	},
+}
+
+var rerunJobCmd = &cobra.Command{
+	Use:   "rerun <job id>",
+	Short: "Re-runs a job with its original configuration",
+	Long:  `Launches a new job using the machine config the specified job ran with, rather than the schedule's current definition.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to convert job ID to integer: %w", err)
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		job, err := b.RerunJob(cmd.Context(), jobID)
+		if err != nil {
+			return fmt.Errorf("failed to rerun job: %w", err)
+		}
+
+		return writeOutput(cmd, newJobOutput(*job), func() error {
+			fmt.Printf("Job %d started as a rerun of job %d\n", job.ID, jobID)
+			return nil
+		})
+	},
+}
+
+var pruneJobsCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Prunes job history according to the retention policies",
+	Long:  `Deletes finished jobs that fall outside each schedule's retention policy, adding them to the daily summaries first.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return err
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		results, err := b.PruneJobs(cmd.Context(), dryRun)
+		if err != nil {
+			return fmt.Errorf("failed to prune jobs: %w", err)
+		}
+
+		output := pruneOutput{DryRun: dryRun, Schedules: results}
+		if output.Schedules == nil {
+			output.Schedules = []cron.PruneResult{}
+		}
+
+		return writeOutput(cmd, output, func() error {
+			if len(results) == 0 {
+				fmt.Println("No jobs to prune")
+				return nil
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Schedule ID", "Schedule", "Jobs"})
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+			for _, result := range results {
+				table.Append([]string{
+					strconv.Itoa(result.ScheduleID),
+					result.ScheduleName,
+					strconv.Itoa(result.Jobs),
+				})
+			}
+
+			if dryRun {
+				fmt.Println("The following jobs would be pruned")
+			}
+
+			table.Render()
+
+			return nil
+		})
 	},
 }
 
+func formatRerunOf(job *cron.Job) string {
+	if !job.RerunOf.Valid {
+		return ""
+	}
+	return strconv.FormatInt(job.RerunOf.Int64, 10)
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.Round(time.Millisecond).String()
+}
+
+func formatSeconds(seconds float64) string {
+	return formatDuration(time.Duration(seconds * float64(time.Second)))
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format("2006-01-02 15:04:05 UTC")
+}
+
 var syncCrontabCmd = &cobra.Command{
 	Use:   "sync",
 	Short: "Syncs sqlite schedules with crontab",
@@ -249,17 +839,42 @@ var syncCrontabCmd = &cobra.Command{
 	Args:  cobra.NoArgs,
 
 	RunE: func(cmd *cobra.Command, args []string) error {
-		store, err := cron.NewStore(cron.DefaultMigrationsPath)
+		b, err := newBackend(cmd)
 		if err != nil {
-			return fmt.Errorf("failed to create store: %w", err)
+			return err
 		}
 
-		if err := cron.SyncSchedules(cmd.Context(), store, log, cron.DefaultSchedulesFilePath); err != nil {
+		if err := b.SyncSchedules(cmd.Context()); err != nil {
 			return fmt.Errorf("failed to sync crontab: %w", err)
 		}
 
-		fmt.Println("Crontab synced successfully")
+		output := messageOutput{Message: "Crontab synced successfully"}
 
-		return nil
+		return writeOutput(cmd, output, func() error {
+			fmt.Println(output.Message)
+			return nil
+		})
 	},
 }
+
+// scheduleImage returns the image jobs are launched with, which is the pinned digest when the image is pinned.
+func scheduleImage(schedule cron.Schedule) string {
+	if schedule.PinImage && schedule.PinnedImage != "" {
+		return schedule.PinnedImage
+	}
+	return schedule.Config.Image
+}
+
+// scheduleRegions lists the regions a schedule launches in, in failover order.
+func scheduleRegions(schedule cron.Schedule) string {
+	if len(schedule.Regions) == 0 {
+		return schedule.Region
+	}
+
+	regions := strings.Join(schedule.Regions, ", ")
+	if schedule.RegionStrategy == cron.RegionStrategyRandom {
+		regions += " (random)"
+	}
+
+	return regions
+}
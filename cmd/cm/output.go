@@ -0,0 +1,542 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/spf13/cobra"
+	fly "github.com/superfly/fly-go"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats supported by every command with --output.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputYAML  = "yaml"
+	outputCSV   = "csv"
+)
+
+// tabular is implemented by every output so it can be written as CSV.
+type tabular interface {
+	header() []string
+	rows() [][]string
+}
+
+func outputFormat(cmd *cobra.Command) (string, error) {
+	format, err := cmd.Flags().GetString("output")
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case outputTable, outputJSON, outputYAML, outputCSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported output format %q, expected one of json, yaml, csv or table", format)
+	}
+}
+
+// writeOutput writes the output in the format selected with --output, calling table to render the table format.
+// JSON and YAML share a schema, defined by the json tags of the output.
+func writeOutput(cmd *cobra.Command, output tabular, table func() error) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case outputJSON:
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(output)
+	case outputYAML:
+		return writeYAML(output)
+	case outputCSV:
+		w := csv.NewWriter(os.Stdout)
+		if err := w.Write(output.header()); err != nil {
+			return err
+		}
+		return w.WriteAll(output.rows())
+	default:
+		return table()
+	}
+}
+
+// writeYAML writes the output through its JSON encoding, so keys follow the json tags and keep their order.
+func writeYAML(output any) error {
+	b, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return err
+	}
+	resetYAMLStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	if err := encoder.Close(); err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(buf.Bytes())
+	return err
+}
+
+// resetYAMLStyle drops the flow and quoting styles the nodes were parsed from JSON with.
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func formatNullTimestamp(t *timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.String()
+}
+
+// timestamp is a time written in UTC, in the RFC 3339 format, so a time reads the same in every output format.
+type timestamp time.Time
+
+func newNullTimestamp(t *time.Time) *timestamp {
+	if t == nil {
+		return nil
+	}
+	ts := timestamp(*t)
+	return &ts
+}
+
+func (t timestamp) String() string {
+	return formatTimestamp(time.Time(t))
+}
+
+func (t timestamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+func formatNullInt(n *int64) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.FormatInt(*n, 10)
+}
+
+type scheduleOutput struct {
+	ID             int      `json:"id"`
+	Name           string   `json:"name"`
+	AppName        string   `json:"app_name"`
+	Image          string   `json:"image"`
+	Schedule       string   `json:"schedule"`
+	Regions        []string `json:"regions"`
+	RegionStrategy string   `json:"region_strategy"`
+	MachineMode    string   `json:"machine_mode"`
+	Enabled        bool     `json:"enabled"`
+	Command        string   `json:"command"`
+	// NextRun is nil when the schedule is disabled, or its expression can't be parsed.
+	NextRun *timestamp `json:"next_run"`
+}
+
+func newScheduleOutput(schedule cron.Schedule, runs nextRuns) scheduleOutput {
+	regions := schedule.Regions
+	if len(regions) == 0 {
+		regions = []string{schedule.Region}
+	}
+
+	return scheduleOutput{
+		ID:             schedule.ID,
+		Name:           schedule.Name,
+		AppName:        schedule.AppName,
+		Image:          scheduleImage(schedule),
+		Schedule:       schedule.Schedule,
+		Regions:        regions,
+		RegionStrategy: schedule.RegionStrategy,
+		MachineMode:    schedule.MachineMode,
+		Enabled:        schedule.Enabled,
+		Command:        schedule.Command,
+		NextRun:        scheduleNextRun(schedule, runs),
+	}
+}
+
+func scheduleNextRun(schedule cron.Schedule, runs nextRuns) *timestamp {
+	if !schedule.Enabled || runs.Err != nil || len(runs.Runs) == 0 {
+		return nil
+	}
+
+	return newNullTimestamp(&runs.Runs[0])
+}
+
+type scheduleListOutput []scheduleOutput
+
+func (o scheduleListOutput) header() []string {
+	return []string{"id", "name", "app_name", "image", "schedule", "regions", "region_strategy", "machine_mode", "enabled", "command", "next_run"}
+}
+
+func (o scheduleListOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o))
+	for _, s := range o {
+		regions, _ := json.Marshal(s.Regions)
+		rows = append(rows, []string{
+			strconv.Itoa(s.ID),
+			s.Name,
+			s.AppName,
+			s.Image,
+			s.Schedule,
+			string(regions),
+			s.RegionStrategy,
+			s.MachineMode,
+			strconv.FormatBool(s.Enabled),
+			s.Command,
+			formatNullTimestamp(s.NextRun),
+		})
+	}
+	return rows
+}
+
+type nextRunsOutput struct {
+	ScheduleID   int         `json:"schedule_id"`
+	ScheduleName string      `json:"schedule_name"`
+	Schedule     string      `json:"schedule"`
+	NextRuns     []timestamp `json:"next_runs"`
+}
+
+func (o nextRunsOutput) header() []string {
+	return []string{"schedule_id", "schedule_name", "schedule", "next_run"}
+}
+
+func (o nextRunsOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o.NextRuns))
+	for _, run := range o.NextRuns {
+		rows = append(rows, []string{strconv.Itoa(o.ScheduleID), o.ScheduleName, o.Schedule, run.String()})
+	}
+	return rows
+}
+
+type jobOutput struct {
+	ID            int        `json:"id"`
+	ScheduleID    int        `json:"schedule_id"`
+	Status        string     `json:"status"`
+	MachineID     string     `json:"machine_id"`
+	MachineState  string     `json:"machine_state"`
+	Region        string     `json:"region"`
+	ImageDigest   string     `json:"image_digest"`
+	RerunOf       *int64     `json:"rerun_of"`
+	ExitCode      *int64     `json:"exit_code"`
+	FailureReason string     `json:"failure_reason"`
+	JitterDelay   int        `json:"jitter_delay"`
+	CreatedAt     timestamp  `json:"created_at"`
+	UpdatedAt     timestamp  `json:"updated_at"`
+	FinishedAt    *timestamp `json:"finished_at"`
+}
+
+func newJobOutput(job cron.Job) jobOutput {
+	output := jobOutput{
+		ID:            job.ID,
+		ScheduleID:    job.ScheduleID,
+		Status:        job.Status,
+		MachineID:     job.MachineID.String,
+		MachineState:  job.MachineState.String,
+		Region:        job.Region.String,
+		ImageDigest:   job.ImageDigest.String,
+		FailureReason: job.FailureReason.String,
+		JitterDelay:   job.JitterDelay,
+		CreatedAt:     timestamp(job.CreatedAt),
+		UpdatedAt:     timestamp(job.UpdatedAt),
+	}
+
+	if job.RerunOf.Valid {
+		output.RerunOf = &job.RerunOf.Int64
+	}
+
+	if job.ExitCode.Valid {
+		output.ExitCode = &job.ExitCode.Int64
+	}
+
+	if job.FinishedAt.Valid {
+		output.FinishedAt = newNullTimestamp(&job.FinishedAt.Time)
+	}
+
+	return output
+}
+
+func (o jobOutput) header() []string {
+	return []string{"id", "schedule_id", "status", "machine_id", "machine_state", "region", "image_digest", "rerun_of", "exit_code", "failure_reason", "jitter_delay", "created_at", "updated_at", "finished_at"}
+}
+
+func (o jobOutput) rows() [][]string {
+	return [][]string{o.row()}
+}
+
+func (o jobOutput) row() []string {
+	return []string{
+		strconv.Itoa(o.ID),
+		strconv.Itoa(o.ScheduleID),
+		o.Status,
+		o.MachineID,
+		o.MachineState,
+		o.Region,
+		o.ImageDigest,
+		formatNullInt(o.RerunOf),
+		formatNullInt(o.ExitCode),
+		o.FailureReason,
+		strconv.Itoa(o.JitterDelay),
+		o.CreatedAt.String(),
+		o.UpdatedAt.String(),
+		formatNullTimestamp(o.FinishedAt),
+	}
+}
+
+type jobListOutput []jobOutput
+
+func (o jobListOutput) header() []string {
+	return jobOutput{}.header()
+}
+
+func (o jobListOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o))
+	for _, job := range o {
+		rows = append(rows, job.row())
+	}
+	return rows
+}
+
+type jobEventOutput struct {
+	Timestamp timestamp `json:"timestamp"`
+	Type      string    `json:"type"`
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+}
+
+// jobDetailOutput describes a job in full. Durations are in seconds.
+type jobDetailOutput struct {
+	jobOutput
+	QueueTime      float64             `json:"queue_time"`
+	ProvisionTime  float64             `json:"provision_time"`
+	BootTime       float64             `json:"boot_time"`
+	ExecutionTime  float64             `json:"execution_time"`
+	Stdout         string              `json:"stdout"`
+	Stderr         string              `json:"stderr"`
+	LaunchAttempts cron.LaunchAttempts `json:"launch_attempts"`
+	MachineConfig  *fly.MachineConfig  `json:"machine_config"`
+	Events         []jobEventOutput    `json:"events"`
+}
+
+func newJobDetailOutput(job cron.Job, events []cron.JobEvent) (jobDetailOutput, error) {
+	timing := cron.CalculateJobTiming(job, events)
+
+	output := jobDetailOutput{
+		jobOutput:      newJobOutput(job),
+		QueueTime:      timing.Queue.Seconds(),
+		ProvisionTime:  timing.Provision.Seconds(),
+		BootTime:       timing.Boot.Seconds(),
+		ExecutionTime:  timing.Execution.Seconds(),
+		Stdout:         job.Stdout.String,
+		Stderr:         job.Stderr.String,
+		LaunchAttempts: job.LaunchAttempts,
+		Events:         make([]jobEventOutput, 0, len(events)),
+	}
+
+	if output.LaunchAttempts == nil {
+		output.LaunchAttempts = cron.LaunchAttempts{}
+	}
+
+	if job.ConfigSnapshot.Valid {
+		config, err := job.MachineConfig()
+		if err != nil {
+			return output, err
+		}
+		output.MachineConfig = config
+	}
+
+	for _, event := range events {
+		output.Events = append(output.Events, jobEventOutput{
+			Timestamp: timestamp(event.Timestamp),
+			Type:      event.Type,
+			Status:    event.Status,
+			Source:    event.Source,
+		})
+	}
+
+	return output, nil
+}
+
+// The CSV of a job's details leaves out the nested launch attempts, machine config and events.
+func (o jobDetailOutput) header() []string {
+	return append(o.jobOutput.header(), "queue_time", "provision_time", "boot_time", "execution_time", "stdout", "stderr")
+}
+
+func (o jobDetailOutput) rows() [][]string {
+	return [][]string{append(o.jobOutput.row(),
+		strconv.FormatFloat(o.QueueTime, 'f', -1, 64),
+		strconv.FormatFloat(o.ProvisionTime, 'f', -1, 64),
+		strconv.FormatFloat(o.BootTime, 'f', -1, 64),
+		strconv.FormatFloat(o.ExecutionTime, 'f', -1, 64),
+		o.Stdout,
+		o.Stderr,
+	)}
+}
+
+type scheduleStatsOutput struct {
+	cron.ScheduleStats
+	LastSuccessAt *timestamp `json:"last_success_at"`
+	LastFailureAt *timestamp `json:"last_failure_at"`
+}
+
+func newScheduleStatsOutput(stats cron.ScheduleStats) scheduleStatsOutput {
+	return scheduleStatsOutput{
+		ScheduleStats: stats,
+		LastSuccessAt: newNullTimestamp(stats.LastSuccessAt),
+		LastFailureAt: newNullTimestamp(stats.LastFailureAt),
+	}
+}
+
+type scheduleStatsListOutput []scheduleStatsOutput
+
+func (o scheduleStatsListOutput) header() []string {
+	return []string{"schedule_id", "schedule_name", "window", "jobs", "succeeded", "failed", "success_rate", "p50_duration", "p95_duration", "max_duration", "last_success_at", "last_failure_at", "failure_streak", "slo_violations"}
+}
+
+func (o scheduleStatsListOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o))
+	for _, stats := range o {
+		violations, _ := json.Marshal(stats.SLOViolations)
+		rows = append(rows, []string{
+			strconv.Itoa(stats.ScheduleID),
+			stats.ScheduleName,
+			stats.Window,
+			strconv.Itoa(stats.Jobs),
+			strconv.Itoa(stats.Succeeded),
+			strconv.Itoa(stats.Failed),
+			strconv.FormatFloat(stats.SuccessRate, 'f', -1, 64),
+			strconv.FormatFloat(stats.P50Duration, 'f', -1, 64),
+			strconv.FormatFloat(stats.P95Duration, 'f', -1, 64),
+			strconv.FormatFloat(stats.MaxDuration, 'f', -1, 64),
+			formatNullTimestamp(stats.LastSuccessAt),
+			formatNullTimestamp(stats.LastFailureAt),
+			strconv.Itoa(stats.FailureStreak),
+			string(violations),
+		})
+	}
+	return rows
+}
+
+type pruneOutput struct {
+	DryRun    bool               `json:"dry_run"`
+	Schedules []cron.PruneResult `json:"schedules"`
+}
+
+func (o pruneOutput) header() []string {
+	return []string{"schedule_id", "schedule_name", "jobs", "dry_run"}
+}
+
+func (o pruneOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o.Schedules))
+	for _, result := range o.Schedules {
+		rows = append(rows, []string{
+			strconv.Itoa(result.ScheduleID),
+			result.ScheduleName,
+			strconv.Itoa(result.Jobs),
+			strconv.FormatBool(o.DryRun),
+		})
+	}
+	return rows
+}
+
+type messageOutput struct {
+	Message string `json:"message"`
+}
+
+func (o messageOutput) header() []string {
+	return []string{"message"}
+}
+
+func (o messageOutput) rows() [][]string {
+	return [][]string{{o.Message}}
+}
+
+type pauseOutput struct {
+	ID           int        `json:"id"`
+	ScheduleName string     `json:"schedule_name"`
+	AppName      string     `json:"app_name"`
+	Reason       string     `json:"reason"`
+	Until        *timestamp `json:"until"`
+	CreatedAt    timestamp  `json:"created_at"`
+}
+
+func newPauseOutput(p cron.Pause) pauseOutput {
+	o := pauseOutput{
+		ID:           p.ID,
+		ScheduleName: p.ScheduleName,
+		AppName:      p.AppName,
+		Reason:       p.Reason,
+		CreatedAt:    timestamp(p.CreatedAt),
+	}
+	if p.Until.Valid {
+		o.Until = newNullTimestamp(&p.Until.Time)
+	}
+	return o
+}
+
+func (o pauseOutput) header() []string {
+	return []string{"id", "schedule_name", "app_name", "reason", "until", "created_at"}
+}
+
+func (o pauseOutput) row() []string {
+	return []string{
+		strconv.Itoa(o.ID),
+		o.ScheduleName,
+		o.AppName,
+		o.Reason,
+		formatNullTimestamp(o.Until),
+		o.CreatedAt.String(),
+	}
+}
+
+func (o pauseOutput) rows() [][]string {
+	return [][]string{o.row()}
+}
+
+type pauseListOutput []pauseOutput
+
+func (o pauseListOutput) header() []string {
+	return pauseOutput{}.header()
+}
+
+func (o pauseListOutput) rows() [][]string {
+	rows := make([][]string, 0, len(o))
+	for _, p := range o {
+		rows = append(rows, p.row())
+	}
+	return rows
+}
+
+type resumeOutput struct {
+	Resumed int `json:"resumed"`
+}
+
+func (o resumeOutput) header() []string {
+	return []string{"resumed"}
+}
+
+func (o resumeOutput) rows() [][]string {
+	return [][]string{{strconv.Itoa(o.Resumed)}}
+}
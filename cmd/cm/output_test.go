@@ -0,0 +1,51 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+)
+
+func TestJobOutputTimestamps(t *testing.T) {
+	zone := time.FixedZone("EDT", -4*60*60)
+	createdAt := time.Date(2026, 10, 18, 8, 30, 0, 123456789, zone)
+
+	output := newJobOutput(cron.Job{
+		ID:         1,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+		FinishedAt: sql.NullTime{Time: createdAt.Add(time.Minute), Valid: true},
+	})
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	header, row := output.header(), output.rows()[0]
+	csv := make(map[string]string, len(header))
+	for i, column := range header {
+		csv[column] = row[i]
+	}
+
+	for column, expected := range map[string]string{
+		"created_at":  "2026-10-18T12:30:00Z",
+		"updated_at":  "2026-10-18T12:30:00Z",
+		"finished_at": "2026-10-18T12:31:00Z",
+	} {
+		if decoded[column] != expected {
+			t.Errorf("expected JSON %s %s, got %v", column, expected, decoded[column])
+		}
+		if csv[column] != expected {
+			t.Errorf("expected CSV %s %s, got %s", column, expected, csv[column])
+		}
+	}
+}
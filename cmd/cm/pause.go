@@ -0,0 +1,170 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// parseUntilFlag parses a timestamp, or a duration from now.
+func parseUntilFlag(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+
+	d, err := cron.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected a timestamp or duration, got %q", value)
+	}
+
+	return time.Now().Add(d), nil
+}
+
+var pauseCmd = &cobra.Command{
+	Use:   "pause",
+	Short: "Pauses scheduled jobs",
+	Long: `Skips the scheduled runs of a schedule, the schedules of an app, or every schedule when neither is set,
+until they're resumed or the pause expires. Skipped runs are recorded as skipped jobs.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var pause cron.Pause
+
+		var err error
+		if pause.ScheduleName, err = cmd.Flags().GetString("schedule"); err != nil {
+			return err
+		}
+		if pause.AppName, err = cmd.Flags().GetString("app"); err != nil {
+			return err
+		}
+		if pause.Reason, err = cmd.Flags().GetString("reason"); err != nil {
+			return err
+		}
+
+		until, err := cmd.Flags().GetString("until")
+		if err != nil {
+			return err
+		}
+
+		if until != "" {
+			t, err := parseUntilFlag(until)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			pause.Until = sql.NullTime{Time: t, Valid: true}
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		created, err := b.Pause(cmd.Context(), pause)
+		if err != nil {
+			return fmt.Errorf("failed to pause: %w", err)
+		}
+
+		return writeOutput(cmd, newPauseOutput(*created), func() error {
+			if created.Until.Valid {
+				fmt.Printf("Paused %s until %s\n", created.Scope(), formatTimestamp(created.Until.Time))
+			} else {
+				fmt.Printf("Paused %s until resumed\n", created.Scope())
+			}
+			return nil
+		})
+	},
+}
+
+var listPausesCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Lists active pauses",
+	Long:  `Lists the pauses that haven't been resumed or expired.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		pauses, err := b.ListPauses(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list pauses: %w", err)
+		}
+
+		output := make(pauseListOutput, 0, len(pauses))
+		for _, pause := range pauses {
+			output = append(output, newPauseOutput(pause))
+		}
+
+		return writeOutput(cmd, output, func() error {
+			if len(pauses) == 0 {
+				fmt.Println("No active pauses")
+				return nil
+			}
+
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"ID", "Scope", "Reason", "Until", "Created At"})
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+
+			for _, pause := range output {
+				table.Append([]string{
+					strconv.Itoa(pause.ID),
+					cron.Pause{ScheduleName: pause.ScheduleName, AppName: pause.AppName}.Scope(),
+					pause.Reason,
+					formatNullTimestamp(pause.Until),
+					pause.CreatedAt.String(),
+				})
+			}
+
+			table.Render()
+			return nil
+		})
+	},
+}
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Resumes paused scheduled jobs",
+	Long: `Removes the pauses of the schedule or app, or the pauses of every schedule when neither is set.
+Pauses of a single schedule aren't removed by resuming its app, nor by resuming every schedule.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		scheduleName, err := cmd.Flags().GetString("schedule")
+		if err != nil {
+			return err
+		}
+
+		appName, err := cmd.Flags().GetString("app")
+		if err != nil {
+			return err
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		resumed, err := b.Resume(cmd.Context(), scheduleName, appName)
+		if err != nil {
+			return fmt.Errorf("failed to resume: %w", err)
+		}
+
+		scope := cron.Pause{ScheduleName: scheduleName, AppName: appName}.Scope()
+
+		return writeOutput(cmd, resumeOutput{Resumed: resumed}, func() error {
+			if resumed == 0 {
+				fmt.Printf("No pauses of %s to resume\n", scope)
+				return nil
+			}
+
+			fmt.Printf("Resumed %s, %d pause(s) removed\n", scope, resumed)
+			return nil
+		})
+	},
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/cron"
+	"github.com/spf13/cobra"
+)
+
+const watchInterval = 2 * time.Second
+
+// exitError exits cm with the code, without printing an error, so cm can mirror a job's exit code.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
+// watchJob follows the job until it reaches a terminal state. The table format streams status transitions,
+// Machine state and logs as they become available, while other formats write the finished job.
+// The returned error carries the job's exit code when it did not complete successfully.
+func watchJob(cmd *cobra.Command, b backend, jobID int) error {
+	format, err := outputFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	var (
+		w      jobWatcher
+		job    *cron.Job
+		events []cron.JobEvent
+	)
+
+	for {
+		job, events, err = b.FindJob(cmd.Context(), jobID)
+		if err != nil {
+			return fmt.Errorf("failed to find job: %w", err)
+		}
+
+		if format == outputTable {
+			w.print(job)
+		}
+
+		if isTerminal(job.Status) {
+			break
+		}
+
+		select {
+		case <-cmd.Context().Done():
+			return cmd.Context().Err()
+		case <-time.After(watchInterval):
+		}
+	}
+
+	if format != outputTable {
+		output, err := newJobDetailOutput(*job, events)
+		if err != nil {
+			return err
+		}
+
+		if err := writeOutput(cmd, output, func() error { return nil }); err != nil {
+			return err
+		}
+	}
+
+	if code := jobExitCode(job); code != 0 {
+		cmd.SilenceErrors = true
+		cmd.SilenceUsage = true
+		return &exitError{code: code}
+	}
+
+	return nil
+}
+
+// jobWatcher prints what changed on a job since it was last seen.
+type jobWatcher struct {
+	status, machineState string
+	stdout, stderr       int
+}
+
+func (w *jobWatcher) print(job *cron.Job) {
+	timestamp := time.Now().UTC().Format(time.TimeOnly)
+
+	if job.Status != w.status {
+		w.status = job.Status
+		fmt.Printf("%s  job %d %s\n", timestamp, job.ID, job.Status)
+	}
+
+	if job.MachineState.Valid && job.MachineState.String != w.machineState {
+		w.machineState = job.MachineState.String
+		fmt.Printf("%s  machine %s %s\n", timestamp, job.MachineID.String, job.MachineState.String)
+	}
+
+	w.stdout = printNewOutput(job.Stdout.String, w.stdout, "stdout")
+	w.stderr = printNewOutput(job.Stderr.String, w.stderr, "stderr")
+
+	if isTerminal(job.Status) {
+		if job.ExitCode.Valid {
+			fmt.Printf("%s  exit code %d\n", timestamp, job.ExitCode.Int64)
+		}
+		if job.FailureReason.Valid {
+			fmt.Printf("%s  failure reason %s\n", timestamp, job.FailureReason.String)
+		}
+	}
+}
+
+// printNewOutput prints the output from the offset on, prefixing each line with the stream, and returns the new offset.
+func printNewOutput(output string, offset int, stream string) int {
+	if len(output) <= offset {
+		return offset
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(output[offset:], "\n"), "\n") {
+		fmt.Printf("%s | %s\n", stream, line)
+	}
+
+	return len(output)
+}
+
+func isTerminal(status string) bool {
+	return status != cron.JobStatusPending && status != cron.JobStatusRunning
+}
+
+// jobExitCode is the job's exit code, or 1 when the job did not complete and has no exit code of its own.
+func jobExitCode(job *cron.Job) int {
+	if job.ExitCode.Valid && job.ExitCode.Int64 != 0 {
+		return int(job.ExitCode.Int64)
+	}
+
+	if job.Status != cron.JobStatusCompleted {
+		return 1
+	}
+
+	return 0
+}
+
+var watchJobCmd = &cobra.Command{
+	Use:   "watch <job id>",
+	Short: "Follows a job until it finishes",
+	Long:  `Streams the status, Machine state and logs of a job until it finishes, exiting with the job's exit code.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		jobID, err := strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to convert job ID to integer: %w", err)
+		}
+
+		b, err := newBackend(cmd)
+		if err != nil {
+			return err
+		}
+
+		return watchJob(cmd, b, jobID)
+	},
+}
@@ -21,7 +21,12 @@ func main() {
 	}
 	defer func() { _ = store.Close() }()
 
-	if err := cron.MonitorActiveJobs(ctx, store, logger); err != nil {
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		panic(err)
+	}
+
+	if err := cron.MonitorActiveJobs(ctx, store, runtimes, logger); err != nil {
 		panic(err)
 	}
 }
@@ -19,9 +19,11 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	// Ensure required environment variables are set
-	if err := checkRequiredEnvs([]string{"FLY_API_TOKEN"}); err != nil {
-		logger.Fatal(err)
+	// Ensure required environment variables are set, a token is only needed when jobs run on Fly
+	if os.Getenv("EXECUTION_BACKEND") == "" || os.Getenv("EXECUTION_BACKEND") == cron.BackendFly {
+		if err := checkRequiredEnvs([]string{"FLY_API_TOKEN"}); err != nil {
+			logger.Fatal(err)
+		}
 	}
 
 	// Initialize the store
@@ -36,7 +38,17 @@ func main() {
 		}
 	}()
 
-	if err := cron.SyncSchedules(ctx, store, logger, cron.DefaultSchedulesFilePath); err != nil {
+	resolver, err := cron.NewImageResolverFromEnv()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	runtimes, err := cron.NewRuntimeProviderFromEnv()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if err := cron.SyncSchedules(ctx, store, logger, resolver, runtimes, cron.DefaultSchedulesFilePath); err != nil {
 		logger.Warnf("There was a problem syncing your schedules: %s", err)
 	} else if err := cron.SyncCrontab(ctx, store, logger); err != nil {
 		logger.Warnf("Failed to sync crontab: %s", err)
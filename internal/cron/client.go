@@ -2,7 +2,9 @@ package cron
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"strings"
 	"time"
@@ -12,13 +14,15 @@ import (
 	"github.com/superfly/fly-go/tokens"
 )
 
+const exitPollFrequency = time.Second
+
+// FlapsClient is the MachineRuntime backed by the Fly Machines API.
 type FlapsClient struct {
 	appName     string
 	flapsClient *flaps.Client
-	store       *Store
 }
 
-func NewFlapsClient(ctx context.Context, appName string, store *Store) (*FlapsClient, error) {
+func NewFlapsClient(ctx context.Context, appName string) (*FlapsClient, error) {
 	flapsClient, err := flaps.NewWithOptions(ctx, flaps.NewClientOpts{
 		AppName: appName,
 		Tokens: &tokens.Tokens{
@@ -32,30 +36,46 @@ func NewFlapsClient(ctx context.Context, appName string, store *Store) (*FlapsCl
 	return &FlapsClient{
 		appName:     appName,
 		flapsClient: flapsClient,
-		store:       store,
 	}, nil
 }
 
-func (c *FlapsClient) MachineProvision(ctx context.Context, schedule *Schedule, job *Job) (*fly.Machine, error) {
-	machineConfig := fly.LaunchMachineInput{
-		Config: &schedule.Config,
-		Region: schedule.Region,
+func (c *FlapsClient) MachineProvision(ctx context.Context, input fly.LaunchMachineInput) (*fly.Machine, error) {
+	machine, err := c.flapsClient.Launch(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch machine: %w", translateLaunchError(err))
 	}
 
-	machine, err := c.flapsClient.Launch(ctx, machineConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to launch machine: %w", err)
+	return machine, nil
+}
+
+func (c *FlapsClient) MachineStart(ctx context.Context, machineID string) error {
+	if _, err := c.flapsClient.Start(ctx, machineID, ""); err != nil {
+		return fmt.Errorf("failed to start machine: %w", translateFlapsError(err))
 	}
 
-	if err := c.store.UpdateJobMachine(ctx, job.ID, machine.ID); err != nil {
-		return machine, fmt.Errorf("failed to update job machine: %w", err)
+	return nil
+}
+
+func (c *FlapsClient) MachineUpdate(ctx context.Context, machine *fly.Machine, config *fly.MachineConfig) (*fly.Machine, error) {
+	updated, err := c.flapsClient.Update(ctx, fly.LaunchMachineInput{
+		ID:         machine.ID,
+		Region:     machine.Region,
+		Config:     config,
+		SkipLaunch: true,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to update machine: %w", translateFlapsError(err))
 	}
 
-	return machine, nil
+	return updated, nil
 }
 
 func (c *FlapsClient) MachineGet(ctx context.Context, machineID string) (*fly.Machine, error) {
-	return c.flapsClient.Get(ctx, machineID)
+	machine, err := c.flapsClient.Get(ctx, machineID)
+	if err != nil {
+		return nil, translateFlapsError(err)
+	}
+	return machine, nil
 }
 
 func (c *FlapsClient) MachineDestroy(ctx context.Context, machine *fly.Machine) error {
@@ -65,18 +85,84 @@ func (c *FlapsClient) MachineDestroy(ctx context.Context, machine *fly.Machine)
 	}
 
 	if err := c.flapsClient.Destroy(ctx, input, ""); err != nil {
-		if strings.Contains(err.Error(), "404") {
+		if errors.Is(err, flaps.FlapsErrorNotFound) {
 			return nil
 		}
-		return err
+		return translateFlapsError(err)
+	}
+	return nil
+}
+
+func (c *FlapsClient) MachineStop(ctx context.Context, machine *fly.Machine, signal string, timeout time.Duration) error {
+	input := fly.StopMachineInput{
+		ID:      machine.ID,
+		Signal:  signal,
+		Timeout: fly.Duration{Duration: timeout},
+	}
+
+	if err := c.flapsClient.Stop(ctx, input, ""); err != nil {
+		if errors.Is(err, flaps.FlapsErrorNotFound) {
+			return nil
+		}
+		return translateFlapsError(err)
 	}
 	return nil
 }
 
 func (c *FlapsClient) MachineList(ctx context.Context, state string) ([]*fly.Machine, error) {
-	return c.flapsClient.List(ctx, state)
+	machines, err := c.flapsClient.List(ctx, state)
+	if err != nil {
+		return nil, translateFlapsError(err)
+	}
+	return machines, nil
+}
+
+func (c *FlapsClient) WaitForExit(ctx context.Context, machineID string, timeout time.Duration) error {
+	return waitForMachineExit(ctx, c, machineID, timeout)
 }
 
 func (c *FlapsClient) WaitForStatus(ctx context.Context, machine *fly.Machine, targetStatus string) error {
 	return c.flapsClient.Wait(ctx, machine, targetStatus, 30*time.Second)
 }
+
+// translateLaunchError marks launches rejected with a client error as ErrLaunchRejected, unless the error is
+// specific to the region, such as a lack of capacity. Server errors may be specific to the region as well.
+func translateLaunchError(err error) error {
+	var flapsErr *flaps.FlapsError
+	if !errors.As(err, &flapsErr) {
+		return err
+	}
+
+	code := flapsErr.ResponseStatusCode
+	if code < 400 || code >= 500 || code == http.StatusTooManyRequests || code == http.StatusRequestTimeout {
+		return translateFlapsError(err)
+	}
+
+	if status := flapsErr.StatusCode(); status != nil && *status == "insufficient_capacity" {
+		return err
+	}
+
+	body := strings.ToLower(flapsErr.ResponseBodyString())
+	if strings.Contains(body, "capacity") || strings.Contains(body, "region") {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ErrLaunchRejected, err)
+}
+
+// translateFlapsError maps flaps errors onto the runtime agnostic errors the monitor understands.
+func translateFlapsError(err error) error {
+	var flapsErr *flaps.FlapsError
+	if !errors.As(err, &flapsErr) {
+		return err
+	}
+
+	switch flapsErr.ResponseStatusCode {
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrMachineNotFound, err)
+	case http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, err)
+	}
+
+	return err
+}
@@ -0,0 +1,340 @@
+package cron
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds how far ahead Next searches for a matching time, so impossible dates such as Feb 30 terminate.
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// maxNextRuns bounds the number of fire times NextRuns computes.
+const maxNextRuns = 1000
+
+// CronExpression is a parsed cron expression. Each field is a bitset of the values it matches.
+type CronExpression struct {
+	second, minute, hour, dom, month, dow uint64
+	// Following cron, when both the day of month and day of week are restricted a day matching either is matched.
+	domRestricted, dowRestricted bool
+
+	// every is the interval of @every expressions, which fire at each multiple of the interval from midnight.
+	every time.Duration
+	// fields is the standard 5 field form of the expression, without seconds.
+	fields string
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// Sunday may be written as either 0 or 7.
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCronExpression parses a cron expression in the standard format (minute, hour, day of month, month, day of week),
+// optionally preceded by a seconds field. Lists, ranges, steps, month and day names, the @hourly style macros and
+// @every intervals, such as @every 15m, are supported.
+func ParseCronExpression(expr string) (*CronExpression, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	if interval, ok := strings.CutPrefix(expr, "@every "); ok {
+		return parseEvery(strings.TrimSpace(interval))
+	}
+
+	if strings.HasPrefix(expr, "@") {
+		return nil, fmt.Errorf("invalid cron expression %q: unsupported macro, expected one of @yearly, @annually, @monthly, @weekly, @daily, @midnight, @hourly or @every <duration>", expr)
+	}
+
+	fields := strings.Fields(expr)
+
+	// Seconds are optional, and default to the start of the minute
+	seconds := "0"
+	switch len(fields) {
+	case 5:
+	case 6:
+		seconds, fields = fields[0], fields[1:]
+	default:
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, or 6 with seconds, got %d", expr, len(fields))
+	}
+
+	var (
+		c   = CronExpression{fields: strings.Join(fields, " ")}
+		err error
+	)
+
+	if c.second, err = secondField.parse(seconds); err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+	}
+
+	for i, field := range []struct {
+		spec cronField
+		bits *uint64
+	}{
+		{minuteField, &c.minute},
+		{hourField, &c.hour},
+		{domField, &c.dom},
+		{monthField, &c.month},
+		{dowField, &c.dow},
+	} {
+		if *field.bits, err = field.spec.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+
+	// Fold Sunday written as 7 onto 0.
+	if c.dow&(1<<7) != 0 {
+		c.dow = c.dow&^(1<<7) | 1
+	}
+
+	c.domRestricted = !strings.HasPrefix(fields[2], "*")
+	c.dowRestricted = !strings.HasPrefix(fields[4], "*")
+
+	return &c, nil
+}
+
+// parseEvery parses the interval of an @every expression. The interval must divide a day evenly, so runs
+// fall at the same times each day, and be a whole number of seconds.
+func parseEvery(interval string) (*CronExpression, error) {
+	every, err := time.ParseDuration(interval)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression \"@every %s\": %w", interval, err)
+	}
+
+	if every < time.Second || every%time.Second != 0 || (24*time.Hour)%every != 0 {
+		return nil, fmt.Errorf("invalid cron expression \"@every %s\": the interval must be a whole number of seconds that divides a day evenly", interval)
+	}
+
+	return &CronExpression{every: every}, nil
+}
+
+func (f cronField) parse(field string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s field", stepPart, f.name)
+			}
+			step = n
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = f.min, f.max
+		case strings.Contains(rangePart, "-"):
+			lo, hi, _ := strings.Cut(rangePart, "-")
+
+			var err error
+			if start, err = f.value(lo); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(hi); err != nil {
+				return 0, err
+			}
+			if start > end {
+				return 0, fmt.Errorf("invalid range %q in %s field", rangePart, f.name)
+			}
+		default:
+			value, err := f.value(rangePart)
+			if err != nil {
+				return 0, err
+			}
+
+			// A single value with a step, such as 5/15, runs from the value until the end of the range.
+			start, end = value, value
+			if hasStep {
+				end = f.max
+			}
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if n, ok := f.names[strings.ToLower(s)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s field", s, f.name)
+	}
+
+	if n < f.min || n > f.max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d] in %s field", n, f.min, f.max, f.name)
+	}
+
+	return n, nil
+}
+
+// Next returns the first time after t that the expression matches, in t's location.
+// The zero time is returned if the expression never matches.
+func (c *CronExpression) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+		return midnight.Add((t.Sub(midnight)/c.every + 1) * c.every)
+	}
+
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+
+		second, ok := nextBit(c.second, t.Second())
+		if !ok {
+			t = t.Truncate(time.Minute).Add(time.Minute)
+			continue
+		}
+
+		return t.Truncate(time.Minute).Add(time.Duration(second) * time.Second)
+	}
+
+	return time.Time{}
+}
+
+// nextBit returns the lowest bit set in the bitset from the bit on.
+func nextBit(set uint64, from int) (int, bool) {
+	set &^= 1<<uint(from) - 1
+	if set == 0 {
+		return 0, false
+	}
+	return bits.TrailingZeros64(set), true
+}
+
+// NextRuns parses the expression and returns the next count times after t that it matches.
+// Fewer times are returned if the expression stops matching.
+func NextRuns(expr string, t time.Time, count int) ([]time.Time, error) {
+	c, err := ParseCronExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	count = min(count, maxNextRuns)
+
+	runs := make([]time.Time, 0, count)
+	for len(runs) < count {
+		if t = c.Next(t); t.IsZero() {
+			break
+		}
+		runs = append(runs, t)
+	}
+
+	return runs, nil
+}
+
+// CrontabEntries returns the crontab entries that run the command as the expression fires. Cron only runs commands
+// at the start of a minute, so runs at other seconds are delayed with sleep, an entry for each second.
+func (c *CronExpression) CrontabEntries(command string) ([]string, error) {
+	fields, seconds := c.fields, c.second
+
+	if c.every > 0 {
+		var err error
+		if fields, seconds, err = c.everyFields(); err != nil {
+			return nil, err
+		}
+	}
+
+	var entries []string
+	for second := range 60 {
+		if seconds&(1<<uint(second)) == 0 {
+			continue
+		}
+
+		if second == 0 {
+			entries = append(entries, fmt.Sprintf("%s %s", fields, command))
+			continue
+		}
+
+		entries = append(entries, fmt.Sprintf("%s sleep %d && %s", fields, second, command))
+	}
+
+	return entries, nil
+}
+
+// everyFields translates an @every interval to cron fields, along with the seconds within the minute it fires at.
+func (c *CronExpression) everyFields() (string, uint64, error) {
+	switch {
+	case c.every < time.Minute && time.Minute%c.every == 0:
+		var seconds uint64
+		for second := time.Duration(0); second < time.Minute; second += c.every {
+			seconds |= 1 << uint(second/time.Second)
+		}
+		return "* * * * *", seconds, nil
+	case c.every < time.Hour && c.every%time.Minute == 0 && time.Hour%c.every == 0:
+		return fmt.Sprintf("*/%d * * * *", c.every/time.Minute), 1, nil
+	case c.every%time.Hour == 0:
+		// Intervals divide a day evenly, so whole hours divide it as well
+		return fmt.Sprintf("0 */%d * * *", c.every/time.Hour), 1, nil
+	default:
+		return "", 0, fmt.Errorf("@every %s can not be run by cron, the interval must divide a minute, an hour or a day evenly", c.every)
+	}
+}
+
+func (c *CronExpression) matchesDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+
+	if c.domRestricted && c.dowRestricted {
+		return dom || dow
+	}
+
+	return dom && dow
+}
@@ -0,0 +1,137 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCronExpressionNext(t *testing.T) {
+	// A Saturday
+	from := time.Date(2026, 10, 17, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 17, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 17, 10, 15, 0, 0, time.UTC)},
+		{"0 9-17 * * mon-fri", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"30 2 1,15 * *", time.Date(2026, 11, 1, 2, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 30 feb *", time.Time{}},
+		{"15,45 * * * * *", time.Date(2026, 10, 17, 10, 7, 45, 0, time.UTC)},
+		{"0 */5 * * * *", time.Date(2026, 10, 17, 10, 10, 0, 0, time.UTC)},
+		{"@every 15m", time.Date(2026, 10, 17, 10, 15, 0, 0, time.UTC)},
+		{"@every 20s", time.Date(2026, 10, 17, 10, 7, 40, 0, time.UTC)},
+		{"@every 6h", time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseCronExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.expr, err)
+		}
+
+		if next := expr.Next(from); !next.Equal(tt.next) {
+			t.Errorf("%s: expected %s, got %s", tt.expr, tt.next, next)
+		}
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* * * * mon-sun", "*/0 * * * *", "@reboot", "* * * * * * *", "@every 7m", "@every 1.5s", "@every nope"} {
+		if _, err := ParseCronExpression(expr); err == nil {
+			t.Errorf("expected %q to be invalid", expr)
+		}
+	}
+}
+
+func TestNextRuns(t *testing.T) {
+	from := time.Date(2026, 10, 17, 10, 7, 30, 0, time.UTC)
+
+	runs, err := NextRuns("@hourly", from, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []time.Time{
+		time.Date(2026, 10, 17, 11, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		time.Date(2026, 10, 17, 13, 0, 0, 0, time.UTC),
+	}
+
+	if len(runs) != len(expected) {
+		t.Fatalf("expected %d runs, got %d", len(expected), len(runs))
+	}
+	for i := range expected {
+		if !runs[i].Equal(expected[i]) {
+			t.Errorf("expected run %d at %s, got %s", i, expected[i], runs[i])
+		}
+	}
+
+	if _, err := NextRuns("@sometimes", from, 3); err == nil {
+		t.Error("expected an unsupported macro to be an error")
+	}
+}
+
+func TestCrontabEntries(t *testing.T) {
+	tests := []struct {
+		expr    string
+		entries []string
+	}{
+		{"*/5 * * * *", []string{"*/5 * * * * run"}},
+		{"@daily", []string{"0 0 * * * run"}},
+		{"0,30 0 * * * *", []string{"0 * * * * run", "0 * * * * sleep 30 && run"}},
+		{"@every 20s", []string{"* * * * * run", "* * * * * sleep 20 && run", "* * * * * sleep 40 && run"}},
+		{"@every 15m", []string{"*/15 * * * * run"}},
+		{"@every 2h", []string{"0 */2 * * * run"}},
+	}
+
+	for _, tt := range tests {
+		expr, err := ParseCronExpression(tt.expr)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.expr, err)
+		}
+
+		entries, err := expr.CrontabEntries("run")
+		if err != nil {
+			t.Fatalf("%s: %s", tt.expr, err)
+		}
+
+		if strings.Join(entries, "\n") != strings.Join(tt.entries, "\n") {
+			t.Errorf("%s: expected %q, got %q", tt.expr, tt.entries, entries)
+		}
+	}
+
+	// Runs every 90 minutes fall at different minutes of each hour, which cron can't express
+	expr, err := ParseCronExpression("@every 90m")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := expr.CrontabEntries("run"); err == nil {
+		t.Error("expected @every 90m to be an error")
+	}
+
+	// Standard expressions that aren't understood are passed to cron as is, the seconds field and @every must parse
+	for expr, expected := range map[string]string{
+		"@reboot":      "@reboot " + executeCommand + " 1",
+		"0 0 L * *":    "0 0 L * * " + executeCommand + " 1",
+		"0  9 * * 1#2": "0 9 * * 1#2 " + executeCommand + " 1",
+	} {
+		entries, err := crontabEntries(Schedule{ID: 1, Schedule: expr})
+		if err != nil {
+			t.Fatalf("%s: %s", expr, err)
+		}
+
+		if len(entries) != 1 || entries[0] != expected {
+			t.Errorf("%s: expected %q, got %q", expr, expected, entries)
+		}
+	}
+
+	for _, expr := range []string{"0 0 0 L * *", "@every soon", "* * * *"} {
+		if _, err := crontabEntries(Schedule{ID: 1, Schedule: expr}); err == nil {
+			t.Errorf("expected %s to be an error", expr)
+		}
+	}
+}
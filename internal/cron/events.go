@@ -0,0 +1,182 @@
+package cron
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	eventPollInterval = time.Second
+	// eventBatchSize bounds the number of events read from the store per poll.
+	eventBatchSize = 500
+	// eventBufferSize is the number of events a subscriber may fall behind by before it's dropped.
+	eventBufferSize = 64
+	// eventRetention is how long events are kept for subscribers to resume from.
+	eventRetention = 24 * time.Hour
+)
+
+// Event types. Job events are recorded as a job's status changes.
+const (
+	EventJobCreated      = "job_created"
+	EventJobStarted      = "job_started"
+	EventJobCompleted    = "job_completed"
+	EventJobFailed       = "job_failed"
+	EventJobTimedOut     = "job_timed_out"
+	EventJobCancelled    = "job_cancelled"
+	EventJobSkipped      = "job_skipped"
+	EventSchedulesSynced = "schedules_synced"
+)
+
+// Event is a change of state within the cron manager, such as a job finishing. Unlike job events,
+// which record what happened to a job's Machine, these are kept briefly for subscribers to follow along.
+type Event struct {
+	ID   int    `json:"id" db:"id"`
+	Type string `json:"type" db:"type"`
+	// ScheduleID is 0 for events that apply to every schedule.
+	ScheduleID int       `json:"schedule_id" db:"schedule_id"`
+	JobID      int       `json:"job_id,omitempty" db:"job_id"`
+	Status     string    `json:"status,omitempty" db:"status"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+func jobEventType(status string) string {
+	switch status {
+	case JobStatusPending:
+		return EventJobCreated
+	case JobStatusRunning:
+		return EventJobStarted
+	case JobStatusCompleted:
+		return EventJobCompleted
+	case JobStatusTimedOut:
+		return EventJobTimedOut
+	case JobStatusCancelled:
+		return EventJobCancelled
+	case JobStatusSkipped:
+		return EventJobSkipped
+	default:
+		return EventJobFailed
+	}
+}
+
+// EventFilter selects the events a subscriber receives.
+type EventFilter struct {
+	// ScheduleIDs limits events to those of the schedules, events that apply to every schedule are always included.
+	ScheduleIDs []int
+}
+
+func (f EventFilter) Matches(event Event) bool {
+	return len(f.ScheduleIDs) == 0 || event.ScheduleID == 0 || slices.Contains(f.ScheduleIDs, event.ScheduleID)
+}
+
+// EventBus fans events out to subscribers within a process. Events are published to the store by whichever
+// process causes them, ProcessJob, the monitor or SyncSchedules, as they don't share a process with the bus.
+// The bus polls the store for new events.
+type EventBus struct {
+	store    *Store
+	log      *logrus.Logger
+	interval time.Duration
+
+	mu          sync.Mutex
+	subscribers map[chan Event]EventFilter
+	stopped     bool
+}
+
+func NewEventBus(store *Store, log *logrus.Logger) *EventBus {
+	return &EventBus{
+		store:       store,
+		log:         log,
+		interval:    eventPollInterval,
+		subscribers: make(map[chan Event]EventFilter),
+	}
+}
+
+// Subscribe returns a channel of the events matching the filter, along with a function to unsubscribe.
+// The channel is closed once the bus stops, or if the subscriber falls too far behind.
+func (b *EventBus) Subscribe(filter EventFilter) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, eventBufferSize)
+	if b.stopped {
+		close(ch)
+		return ch, func() {}
+	}
+
+	b.subscribers[ch] = filter
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Run publishes the events recorded from now on to subscribers, until the context is done.
+func (b *EventBus) Run(ctx context.Context) error {
+	defer b.stop()
+
+	last, err := b.store.LastEventID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			events, err := b.store.ListEventsAfter(ctx, last, eventBatchSize)
+			if err != nil {
+				b.log.WithError(err).Error("failed to list events")
+				continue
+			}
+
+			for _, event := range events {
+				b.publish(event)
+				last = event.ID
+			}
+		}
+	}
+}
+
+func (b *EventBus) publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch, filter := range b.subscribers {
+		if !filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case ch <- event:
+		default:
+			// Drop the subscriber rather than hold up the others, it may resume from the last event it received.
+			b.log.Warn("Dropped an event subscriber that fell behind")
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (b *EventBus) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.stopped = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
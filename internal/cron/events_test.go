@@ -0,0 +1,86 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestEventBus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+
+	store, _, schedule := setupJobTest(t)
+
+	bus := NewEventBus(store, logrus.New())
+	bus.interval = 10 * time.Millisecond
+
+	all, unsubscribeAll := bus.Subscribe(EventFilter{})
+	defer unsubscribeAll()
+
+	other, unsubscribeOther := bus.Subscribe(EventFilter{ScheduleIDs: []int{schedule.ID + 1}})
+	defer unsubscribeOther()
+
+	done := make(chan error)
+	go func() { done <- bus.Run(ctx) }()
+
+	// Give the bus a moment to start from the latest event
+	time.Sleep(50 * time.Millisecond)
+
+	job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateJobStatus(ctx, job.ID, JobStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.TimeoutJob(ctx, job.ID, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RecordEvent(ctx, Event{Type: EventSchedulesSynced}); err != nil {
+		t.Fatal(err)
+	}
+
+	receive := func(ch <-chan Event) Event {
+		t.Helper()
+		select {
+		case event := <-ch:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+			return Event{}
+		}
+	}
+
+	for _, expected := range []struct {
+		eventType, status string
+	}{
+		{EventJobCreated, JobStatusPending},
+		{EventJobStarted, JobStatusRunning},
+		{EventJobTimedOut, JobStatusTimedOut},
+	} {
+		event := receive(all)
+		if event.Type != expected.eventType || event.Status != expected.status || event.JobID != job.ID || event.ScheduleID != schedule.ID {
+			t.Errorf("expected %s event for job %d, got %+v", expected.eventType, job.ID, event)
+		}
+	}
+
+	if event := receive(all); event.Type != EventSchedulesSynced {
+		t.Errorf("expected %s event, got %+v", EventSchedulesSynced, event)
+	}
+
+	// The filtered subscriber only receives the events that apply to every schedule
+	if event := receive(other); event.Type != EventSchedulesSynced {
+		t.Errorf("expected %s event, got %+v", EventSchedulesSynced, event)
+	}
+
+	cancel()
+	<-done
+
+	if _, ok := <-all; ok {
+		t.Error("expected subscription to be closed once the bus stopped")
+	}
+}
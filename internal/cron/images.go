@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	fly "github.com/superfly/fly-go"
+)
+
+// ImageResolver resolves a mutable image reference, such as a tag, to its digest.
+type ImageResolver interface {
+	ResolveImage(ctx context.Context, appName, image string) (digest string, err error)
+}
+
+// FlyImageResolver resolves images through the Fly API.
+type FlyImageResolver struct {
+	client *fly.Client
+}
+
+func NewFlyImageResolver() *FlyImageResolver {
+	return &FlyImageResolver{
+		client: fly.NewClientFromOptions(fly.ClientOptions{
+			AccessToken: os.Getenv("FLY_API_TOKEN"),
+			Name:        "cron-manager",
+		}),
+	}
+}
+
+func (r *FlyImageResolver) ResolveImage(ctx context.Context, appName, image string) (string, error) {
+	resolved, err := r.client.ResolveImageForApp(ctx, appName, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve image %s: %w", image, err)
+	}
+
+	if resolved == nil || resolved.Digest == "" {
+		return "", fmt.Errorf("image %s could not be found", image)
+	}
+
+	return resolved.Digest, nil
+}
+
+// NewImageResolverFromEnv returns the resolver for the configured execution backend.
+// Returns nil for the process backend, which does not run images.
+func NewImageResolverFromEnv() (ImageResolver, error) {
+	switch backend := getEnvOrDefault("EXECUTION_BACKEND", BackendFly); backend {
+	case BackendFly:
+		return NewFlyImageResolver(), nil
+	case BackendDocker:
+		return NewLocalRuntime(backend, getEnvOrDefault("LOCAL_STATE_DIR", DefaultLocalStateDir))
+	case BackendProcess:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported execution backend %q", backend)
+	}
+}
+
+// pinImage replaces the tag or digest of the image with the specified digest.
+func pinImage(image, digest string) string {
+	if i := strings.Index(image, "@"); i != -1 {
+		image = image[:i]
+	}
+
+	// A colon after the last slash separates the tag, rather than a registry port.
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+
+	return image + "@" + digest
+}
@@ -0,0 +1,55 @@
+package cron
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// SpreadFromEnv returns the window set with JOB_SPREAD that scheduled jobs are spread over, unless their schedule
+// sets a jitter of its own. Jobs are launched as soon as they're triggered when it's unset or 0.
+func SpreadFromEnv() (time.Duration, error) {
+	spread, err := ParseDuration(getEnvOrDefault("JOB_SPREAD", "0"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid JOB_SPREAD: %w", err)
+	}
+
+	if spread < 0 {
+		return 0, fmt.Errorf("invalid JOB_SPREAD: must not be negative")
+	}
+
+	return spread, nil
+}
+
+// jitter returns the schedule's jitter, or the spread when the schedule doesn't set one.
+func (s Schedule) jitter(spread time.Duration) (time.Duration, error) {
+	if s.Jitter == "" {
+		return spread, nil
+	}
+
+	d, err := ParseDuration(s.Jitter)
+	if err != nil {
+		return 0, fmt.Errorf("invalid jitter %q: %w", s.Jitter, err)
+	}
+
+	if d < 0 {
+		return 0, fmt.Errorf("invalid jitter %q: must not be negative", s.Jitter)
+	}
+
+	return d, nil
+}
+
+// JitterDelay returns how long the scheduled jobs of the schedule wait before their Machine is launched.
+// The delay is derived from a hash of the schedule's name, so it's stable across restarts, while schedules
+// firing at the same time are spread across the jitter window. Delays are whole seconds less than the window.
+func JitterDelay(schedule Schedule, spread time.Duration) time.Duration {
+	window, err := schedule.jitter(spread)
+	if err != nil || window < time.Second {
+		return 0
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(schedule.Name))
+
+	return time.Duration(h.Sum64()%uint64(window/time.Second)) * time.Second
+}
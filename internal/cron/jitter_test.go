@@ -0,0 +1,121 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestJitterDelay(t *testing.T) {
+	spread := 10 * time.Minute
+
+	delays := make(map[time.Duration]bool)
+	for i := 0; i < 20; i++ {
+		schedule := Schedule{Name: fmt.Sprintf("schedule-%d", i)}
+
+		delay := JitterDelay(schedule, spread)
+		if delay < 0 || delay >= spread {
+			t.Errorf("expected delay of %s within [0, %s), got %s", schedule.Name, spread, delay)
+		}
+		if delay%time.Second != 0 {
+			t.Errorf("expected delay of %s in whole seconds, got %s", schedule.Name, delay)
+		}
+		if again := JitterDelay(schedule, spread); again != delay {
+			t.Errorf("expected delay of %s to be stable, got %s and %s", schedule.Name, delay, again)
+		}
+		delays[delay] = true
+	}
+
+	if len(delays) < 2 {
+		t.Errorf("expected schedules to be spread across the window, got %v", delays)
+	}
+
+	schedule := Schedule{Name: "schedule-0"}
+
+	if delay := JitterDelay(schedule, 0); delay != 0 {
+		t.Errorf("expected no delay without jitter or spread, got %s", delay)
+	}
+
+	schedule.Jitter = "0s"
+	if delay := JitterDelay(schedule, spread); delay != 0 {
+		t.Errorf("expected a jitter of 0s to override the spread, got %s", delay)
+	}
+
+	schedule.Jitter = "30s"
+	if delay := JitterDelay(schedule, spread); delay >= 30*time.Second {
+		t.Errorf("expected the jitter to override the spread, got %s", delay)
+	}
+
+	for _, jitter := range []string{"soon", "-5m"} {
+		schedule.Jitter = jitter
+		if _, err := schedule.jitter(spread); err == nil {
+			t.Errorf("expected jitter %q to be invalid", jitter)
+		}
+	}
+}
+
+func TestProcessScheduledJob(t *testing.T) {
+	store, runtime, schedule := setupJobTest(t)
+
+	job, err := ProcessScheduledJob(context.TODO(), logrus.New(), store, runtime, schedule.ID, 0, ConcurrencyLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != JobStatusRunning || job.JitterDelay != 0 {
+		t.Errorf("expected job to be launched without delay, got status %s and delay %d", job.Status, job.JitterDelay)
+	}
+
+	schedule.Jitter = "1h"
+	if err := store.UpdateSchedule(context.TODO(), *schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := JitterDelay(*schedule, 0)
+	if expected == 0 {
+		t.Skip("schedule has no jitter delay within the window")
+	}
+
+	ctx := context.TODO()
+	log := logrus.New()
+
+	delayed, err := ProcessScheduledJob(ctx, log, store, runtime, schedule.ID, 0, ConcurrencyLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if delayed.ID == job.ID {
+		t.Fatalf("expected a second job to be created")
+	}
+
+	if delayed.JitterDelay != int(expected.Seconds()) {
+		t.Errorf("expected jitter delay of %d seconds to be recorded, got %d", int(expected.Seconds()), delayed.JitterDelay)
+	}
+
+	// The job is returned right away, waiting in the launch queue
+	if delayed.Status != JobStatusPending || delayed.MachineID.Valid {
+		t.Fatalf("expected job to be pending without a machine, got status %s", delayed.Status)
+	}
+
+	if !delayed.NotBefore.Valid || delayed.NotBefore.Time.Before(delayed.CreatedAt.Add(expected-time.Second)) {
+		t.Fatalf("expected the job not to be launched before its delay has passed, got %v", delayed.NotBefore)
+	}
+
+	if err := DispatchJobs(ctx, log, store, runtime, ConcurrencyLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	assertQueueTestStatus(t, store, delayed.ID, JobStatusPending)
+
+	// Once the delay has passed, the job is dispatched
+	if _, err := store.ExecContext(ctx, "UPDATE jobs SET not_before = ? WHERE id = ?", time.Now().Add(-time.Second), delayed.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := DispatchJobs(ctx, log, store, runtime, ConcurrencyLimits{}); err != nil {
+		t.Fatal(err)
+	}
+	assertQueueTestStatus(t, store, delayed.ID, JobStatusRunning)
+}
@@ -2,38 +2,180 @@ package cron
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"time"
+
+	fly "github.com/superfly/fly-go"
 
 	"github.com/google/shlex"
 	"github.com/sirupsen/logrus"
 )
 
-func ProcessJob(ctx context.Context, log *logrus.Logger, store *Store, scheduleID int) (err error) {
+// managedByMetadataKey is set on every Machine launched by the cron manager.
+const managedByMetadataKey = "managed-by-cron-manager"
+
+func ProcessJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, scheduleID int, limits ConcurrencyLimits) (*Job, error) {
 	schedule, err := store.FindSchedule(ctx, scheduleID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if err := prepareJob(schedule); err != nil {
-		return fmt.Errorf("failed to prepare job: %w", err)
+		return nil, fmt.Errorf("failed to prepare job: %w", err)
 	}
 
-	job, err := store.CreateJob(ctx, schedule.ID)
+	job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
 	if err != nil {
-		return fmt.Errorf("failed to create job: %w", err)
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return queueJob(ctx, log, store, runtimes, limits, job)
+}
+
+// ProcessScheduledJob processes a job triggered by its schedule, delaying the launch of the Machine by the schedule's
+// jitter delay so schedules firing at the same time don't all provision at once. A delayed job is returned right away,
+// pending in the launch queue until the delay has passed. A job cancelled while it waits is not launched.
+// Jobs of schedules that are paused, or within a blackout window, are skipped rather than launched.
+func ProcessScheduledJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, scheduleID int, spread time.Duration, limits ConcurrencyLimits) (*Job, error) {
+	schedule, err := store.FindSchedule(ctx, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareJob(schedule); err != nil {
+		return nil, fmt.Errorf("failed to prepare job: %w", err)
+	}
+
+	job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	if err := store.MarkJobScheduled(ctx, job.ID); err != nil {
+		return nil, fmt.Errorf("failed to mark job as scheduled: %w", err)
+	}
+
+	skipped, err := skipScheduledJob(ctx, log, store, schedule, job)
+	if err != nil {
+		return nil, err
+	}
+	if skipped {
+		return store.FindJob(ctx, fmt.Sprint(job.ID))
+	}
+
+	if delay := JitterDelay(*schedule, spread); delay > 0 {
+		if err := store.SetJobJitterDelay(ctx, job.ID, delay); err != nil {
+			return nil, fmt.Errorf("failed to record jitter delay: %w", err)
+		}
+
+		log.WithFields(logrus.Fields{
+			"app-name": schedule.AppName,
+			"schedule": schedule.Name,
+			"job-id":   job.ID,
+		}).Infof("Delaying launch by %s", delay)
 	}
 
+	return queueJob(ctx, log, store, runtimes, limits, job)
+}
+
+// skipScheduledJob skips the job if its schedule is paused or within a blackout window, leaving a record of the run.
+func skipScheduledJob(ctx context.Context, log *logrus.Logger, store *Store, schedule *Schedule, job *Job) (bool, error) {
+	reason, message, err := skipReason(ctx, store, *schedule, time.Now())
+	if err != nil {
+		return false, fmt.Errorf("failed to check pauses: %w", err)
+	}
+
+	if reason == "" {
+		return false, nil
+	}
+
+	log.WithFields(logrus.Fields{
+		"app-name": schedule.AppName,
+		"schedule": schedule.Name,
+		"job-id":   job.ID,
+	}).Infof("Skipping job, %s", message)
+
+	if _, err := store.SkipJob(ctx, job.ID, reason, message); err != nil {
+		return false, fmt.Errorf("failed to skip job: %w", err)
+	}
+
+	return true, nil
+}
+
+// RerunJob launches a new job with the exact Machine config of a previous job, rather than the schedule's current definition.
+// The new job links back to the original.
+func RerunJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, jobID int, limits ConcurrencyLimits) (*Job, error) {
+	schedule, err := rerunSchedule(ctx, store, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{Int64: int64(jobID), Valid: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return queueJob(ctx, log, store, runtimes, limits, job)
+}
+
+// rerunSchedule returns the schedule of the job, as it was when the job was launched, for the job to be re-run.
+func rerunSchedule(ctx context.Context, store *Store, jobID int) (*Schedule, error) {
+	original, err := store.FindJob(ctx, fmt.Sprint(jobID))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := original.MachineConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, err := store.FindSchedule(ctx, original.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The snapshot was taken after the job was prepared, so it's launched as is.
+	schedule.Config = *config
+
+	// Launch in the region the original ran in, rather than failing over to the schedule's current regions.
+	if original.Region.Valid {
+		schedule.Region = original.Region.String
+	}
+	schedule.Regions = nil
+
+	// Reruns launch a new Machine with the snapshot as is, leaving the schedule's reused Machine untouched.
+	// The monitor destroys the new Machine once the job has finished.
+	if schedule.MachineMode == MachineModeReuse {
+		schedule.MachineMode = MachineModeEphemeral
+	}
+
+	return schedule, nil
+}
+
+// launchJob provisions the Machine for a newly created job, failing the job if it can't be launched.
+func launchJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, schedule *Schedule, job *Job) (err error) {
 	logger := log.WithFields(logrus.Fields{
 		"app-name": schedule.AppName,
 		"schedule": schedule.Name,
 		"job-id":   job.ID,
 	})
 
+	if job.RerunOf.Valid {
+		logger = logger.WithField("rerun-of", job.RerunOf.Int64)
+	}
+
 	// Defer a function to handle job processing errors
 	defer func() {
 		if err != nil {
 			logger.WithError(err).Error("job processing failed")
-			if failErr := store.FailJob(ctx, job.ID, 1, err.Error()); failErr != nil {
+			if _, failErr := store.FailJob(ctx, job.ID, 1, FailureReasonLaunchFailed, err.Error()); failErr != nil {
 				logger.WithError(failErr).Error("failed to update job status")
 			}
 		}
@@ -41,32 +183,317 @@ func ProcessJob(ctx context.Context, log *logrus.Logger, store *Store, scheduleI
 
 	logger.Info("Preparing job...")
 
-	client, err := NewFlapsClient(ctx, schedule.AppName, store)
+	if err := store.SetJobConfigSnapshot(ctx, job.ID, schedule.Config); err != nil {
+		logger.WithError(err).Warn("failed to record config snapshot")
+	}
+
+	runtime, err := runtimes.Runtime(ctx, schedule.AppName)
 	if err != nil {
-		return fmt.Errorf("failed to create client: %w", err)
+		return fmt.Errorf("failed to create runtime: %w", err)
+	}
+
+	launchRequested := JobEvent{
+		JobID:     job.ID,
+		Type:      JobEventLaunchRequested,
+		Source:    jobEventSourceCronManager,
+		Timestamp: time.Now(),
+	}
+	if err := store.RecordJobEvents(ctx, []JobEvent{launchRequested}); err != nil {
+		logger.WithError(err).Warn("failed to record launch event")
+	}
+
+	var (
+		machine *fly.Machine
+		region  string
+	)
+
+	// Schedules in reuse mode start their stopped Machine, launching a new one only if it no longer exists
+	if schedule.MachineMode == MachineModeReuse {
+		machine, err = startScheduleMachine(ctx, logger, store, runtime, schedule)
+		if err != nil {
+			return fmt.Errorf("failed to start machine: %w", err)
+		}
+	}
+
+	if machine != nil {
+		region = machine.Region
+	} else {
+		machine, region, err = launchMachine(ctx, logger, store, runtime, schedule, job.ID)
+		if err != nil {
+			return fmt.Errorf("failed to provision machine: %w", err)
+		}
+
+		if schedule.MachineMode == MachineModeReuse {
+			if err := store.SetScheduleMachine(ctx, schedule.ID, machine.ID, configHash(schedule.Config)); err != nil {
+				logger.WithError(err).Warn("failed to record schedule machine")
+			}
+		}
 	}
 
-	// Provision machine to run the job
-	machine, err := client.MachineProvision(ctx, schedule, job)
-	if err != nil && machine != nil {
-		if destroyErr := client.MachineDestroy(ctx, machine); destroyErr != nil {
+	if err := store.UpdateJobMachine(ctx, job.ID, machine, region); err != nil {
+		if destroyErr := runtime.MachineDestroy(ctx, machine); destroyErr != nil {
 			logger.Warnf("failed to destroy machine %s: %s", machine.ID, destroyErr)
 		}
-		return fmt.Errorf("failed to provision machine: %w", err)
+		return fmt.Errorf("failed to update job machine: %w", err)
 	}
 
 	logger = logger.WithField("machine-id", machine.ID)
 
-	// Set the job status to running
-	if err := store.UpdateJobStatus(ctx, job.ID, JobStatusRunning); err != nil {
+	// Set the job status to running, unless the job was cancelled while its Machine was being launched
+	started, err := store.StartJob(ctx, job.ID)
+	if err != nil {
 		return fmt.Errorf("failed to update job status: %w", err)
 	}
 
+	if !started {
+		logger.Info("Job was cancelled during launch, tearing down its machine")
+		discardMachine(ctx, logger, runtime, schedule, machine)
+		return nil
+	}
+
 	logger.Infof("Running job...")
 
 	return nil
 }
 
+// CancelJob marks a pending or running job as cancelled and tears down its Machine.
+// The Machine is sent the stop signal and given the timeout to exit before it is destroyed.
+// When omitted, the signal and timeout default to the schedule's stop_signal and kill_timeout.
+func CancelJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, jobID int, signal string, timeout time.Duration) error {
+	job, err := store.FindJob(ctx, fmt.Sprint(jobID))
+	if err != nil {
+		return err
+	}
+
+	if job.Status != JobStatusPending && job.Status != JobStatusRunning {
+		return fmt.Errorf("job %d can not be cancelled, status is %s", job.ID, job.Status)
+	}
+
+	schedule, err := store.FindSchedule(ctx, job.ScheduleID)
+	if err != nil {
+		return err
+	}
+
+	if signal == "" {
+		signal = schedule.StopSignal
+	}
+
+	if timeout == 0 {
+		timeout = time.Duration(schedule.KillTimeout) * time.Second
+	}
+
+	logger := log.WithFields(logrus.Fields{
+		"app-name":   schedule.AppName,
+		"schedule":   schedule.Name,
+		"job-id":     job.ID,
+		"machine-id": job.MachineID.String,
+	})
+
+	// Update the status first so the monitor stops evaluating the job while we tear it down.
+	// The job may have finished since it was looked up, in which case its Machine is left to the monitor.
+	cancelled, err := store.CancelJob(ctx, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update job status: %w", err)
+	}
+	if !cancelled {
+		return fmt.Errorf("job %d already finished", job.ID)
+	}
+
+	if !job.MachineID.Valid {
+		logger.Info("Job cancelled before a machine was provisioned")
+		return nil
+	}
+
+	runtime, err := runtimes.Runtime(ctx, schedule.AppName)
+	if err != nil {
+		return fmt.Errorf("failed to create runtime: %w", err)
+	}
+
+	machine, err := runtime.MachineGet(ctx, job.MachineID.String)
+	if err != nil {
+		return fmt.Errorf("failed to get machine: %w", err)
+	}
+
+	if machine.State == fly.MachineStateDestroyed {
+		logger.Info("Job cancelled, machine was already destroyed")
+		return nil
+	}
+
+	logger.Infof("Stopping machine with %s...", signal)
+
+	if err := runtime.MachineStop(ctx, machine, signal, timeout); err != nil {
+		logger.WithError(err).Warn("failed to stop machine")
+	} else if err := runtime.WaitForExit(ctx, machine.ID, timeout); err != nil {
+		logger.WithError(err).Warn("machine did not stop within the grace period")
+	}
+
+	// Reused Machines are kept once they've stopped, so they can be started for the next job.
+	if isScheduleMachine(ctx, store, schedule, machine.ID) {
+		if machine, err := runtime.MachineGet(ctx, machine.ID); err == nil && machine.State == fly.MachineStateStopped {
+			if err := store.SetJobMachineSnapshot(ctx, job.ID, machineEventsSince(machine, job.CreatedAt)); err != nil {
+				logger.WithError(err).Warn("failed to record machine snapshot")
+			}
+			logger.Info("Job cancelled")
+			return nil
+		}
+	}
+
+	if err := runtime.MachineDestroy(ctx, machine); err != nil {
+		return fmt.Errorf("failed to destroy machine %s: %w", machine.ID, err)
+	}
+
+	snapshotMachine(ctx, logger, store, runtime, job.ID, machine.ID)
+
+	logger.Info("Job cancelled")
+
+	return nil
+}
+
+// isScheduleMachine reports whether the Machine is the one kept by a schedule in reuse mode.
+// Reruns of the schedule's jobs launch Machines of their own, which aren't kept.
+func isScheduleMachine(ctx context.Context, store *Store, schedule *Schedule, machineID string) bool {
+	if schedule.MachineMode != MachineModeReuse {
+		return false
+	}
+
+	record, err := store.FindScheduleMachine(ctx, schedule.ID)
+	if err != nil {
+		return false
+	}
+
+	return record.MachineID == machineID
+}
+
+// discardMachine tears down a Machine launched for a job that was cancelled before it started running.
+// Reused Machines are stopped so they can be started for the next job, others are destroyed.
+func discardMachine(ctx context.Context, logger *logrus.Entry, runtime MachineRuntime, schedule *Schedule, machine *fly.Machine) {
+	if schedule.MachineMode == MachineModeReuse {
+		if err := runtime.MachineStop(ctx, machine, schedule.StopSignal, time.Duration(schedule.KillTimeout)*time.Second); err != nil {
+			logger.WithError(err).Warnf("failed to stop machine %s", machine.ID)
+		}
+		return
+	}
+
+	if err := runtime.MachineDestroy(ctx, machine); err != nil {
+		logger.WithError(err).Warnf("failed to destroy machine %s", machine.ID)
+	}
+}
+
+// launchMachine provisions a Machine to run the job, falling through to the next region if the launch fails for a
+// reason that may be specific to the region. Launches that would fail the same way everywhere aren't retried.
+// Returns the Machine along with the region it was launched in.
+func launchMachine(ctx context.Context, logger *logrus.Entry, store *Store, runtime MachineRuntime, schedule *Schedule, jobID int) (*fly.Machine, string, error) {
+	var (
+		machine  *fly.Machine
+		region   string
+		attempts LaunchAttempts
+		err      error
+	)
+	for _, region = range launchRegions(schedule) {
+		machine, err = runtime.MachineProvision(ctx, fly.LaunchMachineInput{
+			Config: &schedule.Config,
+			Region: region,
+		})
+		if err == nil {
+			break
+		}
+
+		logger.WithError(err).Warnf("failed to launch machine in region %q", region)
+		attempts = append(attempts, LaunchAttempt{Region: region, Error: err.Error(), Timestamp: time.Now()})
+
+		if ctx.Err() != nil || errors.Is(err, ErrLaunchRejected) || errors.Is(err, ErrRateLimited) {
+			break
+		}
+	}
+
+	if len(attempts) > 0 {
+		if err := store.SetJobLaunchAttempts(ctx, jobID, attempts); err != nil {
+			logger.WithError(err).Warn("failed to record launch attempts")
+		}
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if machine.Region != "" {
+		region = machine.Region
+	}
+
+	return machine, region, nil
+}
+
+// startScheduleMachine starts the stopped Machine kept for the schedule, updating its config first if the schedule has changed.
+// Returns nil if the schedule has no Machine yet, or its Machine no longer exists.
+func startScheduleMachine(ctx context.Context, logger *logrus.Entry, store *Store, runtime MachineRuntime, schedule *Schedule) (*fly.Machine, error) {
+	record, err := store.FindScheduleMachine(ctx, schedule.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	machine, err := runtime.MachineGet(ctx, record.MachineID)
+	if err != nil && !errors.Is(err, ErrMachineNotFound) {
+		return nil, err
+	}
+
+	if err != nil || machine.State == fly.MachineStateDestroyed || machine.State == fly.MachineStateDestroying {
+		logger.Infof("Machine %s no longer exists, launching a new one", record.MachineID)
+		if err := store.DeleteScheduleMachine(ctx, schedule.ID); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if machine.State != fly.MachineStateStopped {
+		return nil, fmt.Errorf("machine %s is %s, it may still be running a previous job", machine.ID, machine.State)
+	}
+
+	hash := configHash(schedule.Config)
+	if record.ConfigHash != hash {
+		logger.Infof("Updating machine %s with the latest schedule config", machine.ID)
+
+		if machine, err = runtime.MachineUpdate(ctx, machine, &schedule.Config); err != nil {
+			return nil, err
+		}
+
+		if err := store.SetScheduleMachine(ctx, schedule.ID, machine.ID, hash); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := runtime.MachineStart(ctx, machine.ID); err != nil {
+		return nil, err
+	}
+
+	return machine, nil
+}
+
+// configHash identifies a Machine config, so changes to a schedule can be detected.
+func configHash(config fly.MachineConfig) string {
+	b, _ := json.Marshal(config)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// launchRegions returns the regions to launch in, in the order they should be attempted.
+func launchRegions(schedule *Schedule) []string {
+	if len(schedule.Regions) == 0 {
+		return []string{schedule.Region}
+	}
+
+	regions := append([]string(nil), schedule.Regions...)
+	if schedule.RegionStrategy == RegionStrategyRandom {
+		rand.Shuffle(len(regions), func(i, j int) {
+			regions[i], regions[j] = regions[j], regions[i]
+		})
+	}
+
+	return regions
+}
+
 func prepareJob(schedule *Schedule) error {
 	cmdSlice, err := shlex.Split(schedule.Command)
 	if err != nil {
@@ -75,12 +502,22 @@ func prepareJob(schedule *Schedule) error {
 
 	schedule.Config.Init.Cmd = cmdSlice
 
+	// Launch by the digest resolved at sync, rather than a tag that may have moved since
+	if schedule.PinImage && schedule.PinnedImage != "" {
+		schedule.Config.Image = schedule.PinnedImage
+	}
+
 	if schedule.Config.Metadata == nil {
 		schedule.Config.Metadata = make(map[string]string)
 	}
 
+	// Reused Machines must survive their command exiting
+	if schedule.MachineMode == MachineModeReuse {
+		schedule.Config.AutoDestroy = false
+	}
+
 	// Indicate the associated Machine was created by the cron manager
-	schedule.Config.Metadata["managed-by-cron-manager"] = "true"
+	schedule.Config.Metadata[managedByMetadataKey] = "true"
 
 	return nil
 }
@@ -0,0 +1,107 @@
+package cron
+
+import (
+	"time"
+
+	fly "github.com/superfly/fly-go"
+)
+
+const (
+	// JobEventLaunchRequested is recorded by the cron manager right before it asks Fly to launch a Machine.
+	JobEventLaunchRequested = "launch_requested"
+
+	// Recorded by the cron manager as it tears down the Machine of a job that exceeded its command timeout.
+	JobEventStopRequested    = "stop_requested"
+	JobEventGraceElapsed     = "grace_period_elapsed"
+	JobEventDestroyRequested = "destroy_requested"
+
+	// Machine event types reported by Fly.
+	JobEventLaunch  = "launch"
+	JobEventStart   = "start"
+	JobEventExit    = "exit"
+	JobEventDestroy = "destroy"
+
+	jobEventSourceCronManager = "cron-manager"
+)
+
+type JobEvent struct {
+	ID        int       `json:"id" db:"id"`
+	JobID     int       `json:"job_id" db:"job_id"`
+	Type      string    `json:"type" db:"type"`
+	Status    string    `json:"status" db:"status"`
+	Source    string    `json:"source" db:"source"`
+	Timestamp time.Time `json:"timestamp" db:"timestamp"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// JobTiming breaks down where the time of a job was spent.
+type JobTiming struct {
+	// Queue is the time between the job being created and the cron manager requesting its Machine.
+	Queue time.Duration
+	// Provision is the time between the Machine being requested and the Machine being created.
+	Provision time.Duration
+	// Boot is the time between the Machine being created and the Machine starting.
+	Boot time.Duration
+	// Execution is the time between the Machine starting and the Machine exiting.
+	Execution time.Duration
+}
+
+func convertMachineEvents(jobID int, events []*fly.MachineEvent) []JobEvent {
+	jobEvents := make([]JobEvent, 0, len(events))
+	for _, event := range events {
+		jobEvents = append(jobEvents, JobEvent{
+			JobID:     jobID,
+			Type:      event.Type,
+			Status:    event.Status,
+			Source:    event.Source,
+			Timestamp: event.Time(),
+		})
+	}
+	return jobEvents
+}
+
+// CalculateJobTiming derives the queue, boot and execution time of a job from its recorded events.
+// Phases that have not been reached yet are reported as zero.
+func CalculateJobTiming(job Job, events []JobEvent) JobTiming {
+	var timing JobTiming
+
+	requested := findJobEvent(events, JobEventLaunchRequested)
+	launch := findJobEvent(events, JobEventLaunch)
+	start := findJobEvent(events, JobEventStart)
+	exit := findJobEvent(events, JobEventExit)
+
+	switch {
+	case requested != nil:
+		timing.Queue = requested.Timestamp.Sub(job.CreatedAt)
+		if launch != nil {
+			timing.Provision = launch.Timestamp.Sub(requested.Timestamp)
+		}
+	case launch != nil:
+		// Jobs launched before launch requests were recorded can't tell queueing and provisioning apart
+		timing.Queue = launch.Timestamp.Sub(job.CreatedAt)
+	}
+
+	if launch != nil && start != nil {
+		timing.Boot = start.Timestamp.Sub(launch.Timestamp)
+	}
+
+	if start != nil && exit != nil {
+		timing.Execution = exit.Timestamp.Sub(start.Timestamp)
+	}
+
+	return timing
+}
+
+// findJobEvent returns the earliest event of the specified type.
+func findJobEvent(events []JobEvent, eventType string) *JobEvent {
+	var found *JobEvent
+	for i, event := range events {
+		if event.Type != eventType {
+			continue
+		}
+		if found == nil || event.Timestamp.Before(found.Timestamp) {
+			found = &events[i]
+		}
+	}
+	return found
+}
@@ -0,0 +1,64 @@
+package cron
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+)
+
+func TestRecordJobEvents(t *testing.T) {
+	store, err := InitializeStore(context.TODO(), filepath.Join(t.TempDir(), "state.db"), "../../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = store.Close() }()
+
+	created := time.Now().Add(-time.Minute).Truncate(time.Millisecond)
+	requested := JobEvent{JobID: 1, Type: JobEventLaunchRequested, Source: jobEventSourceCronManager, Timestamp: created.Add(500 * time.Millisecond)}
+	if err := store.RecordJobEvents(context.TODO(), []JobEvent{requested}); err != nil {
+		t.Fatal(err)
+	}
+
+	machineEvents := []*fly.MachineEvent{
+		{Type: JobEventLaunch, Status: "created", Source: "user", Timestamp: created.Add(2 * time.Second).UnixMilli()},
+		{Type: JobEventStart, Status: "started", Source: "flyd", Timestamp: created.Add(5 * time.Second).UnixMilli()},
+		{Type: JobEventExit, Status: "stopped", Source: "flyd", Timestamp: created.Add(15 * time.Second).UnixMilli()},
+	}
+
+	// Events are reported on every poll, recording them twice should not create duplicates.
+	for i := 0; i < 2; i++ {
+		if err := store.RecordJobEvents(context.TODO(), convertMachineEvents(1, machineEvents)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	events, err := store.ListJobEvents(context.TODO(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	timing := CalculateJobTiming(Job{ID: 1, CreatedAt: created}, events)
+
+	if timing.Queue != 500*time.Millisecond {
+		t.Errorf("expected queue time of 500ms, got %s", timing.Queue)
+	}
+
+	if timing.Provision != 1500*time.Millisecond {
+		t.Errorf("expected provision time of 1.5s, got %s", timing.Provision)
+	}
+
+	if timing.Boot != 3*time.Second {
+		t.Errorf("expected boot time of 3s, got %s", timing.Boot)
+	}
+
+	if timing.Execution != 10*time.Second {
+		t.Errorf("expected execution time of 10s, got %s", timing.Execution)
+	}
+}
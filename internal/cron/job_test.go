@@ -0,0 +1,707 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	fly "github.com/superfly/fly-go"
+)
+
+func TestJobLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	log := logrus.New()
+
+	t.Run("completes successful jobs", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if job.Status != JobStatusRunning {
+			t.Fatalf("expected job to be %s, got %s", JobStatusRunning, job.Status)
+		}
+
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		// The machine has started but not exited, the job should remain running
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusRunning {
+			t.Fatalf("expected job to be %s, got %s", JobStatusRunning, job.Status)
+		}
+
+		if err := runtime.ExitMachine(job.MachineID.String, 0, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusCompleted {
+			t.Fatalf("expected job to be %s, got %s", JobStatusCompleted, job.Status)
+		}
+
+		config, err := job.MachineConfig()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(config.Init.Cmd) != 1 || config.Init.Cmd[0] != "uptime" {
+			t.Fatalf("expected the launched command to be snapshotted, got %v", config.Init.Cmd)
+		}
+
+		if job.MachineState.String != fly.MachineStateDestroyed || !job.MachineEvents.Valid {
+			t.Fatalf("expected the final machine state to be snapshotted, got %q", job.MachineState.String)
+		}
+
+		events, err := store.ListJobEvents(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorded := make(map[string]bool)
+		for _, event := range events {
+			recorded[event.Type] = true
+		}
+
+		for _, eventType := range []string{JobEventLaunchRequested, JobEventLaunch, JobEventStart, JobEventExit, JobEventDestroy} {
+			if !recorded[eventType] {
+				t.Errorf("expected a %s event to be recorded", eventType)
+			}
+		}
+	})
+
+	t.Run("fails jobs with a non-zero exit code", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := runtime.ExitMachine(job.MachineID.String, 3, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusFailed {
+			t.Fatalf("expected job to be %s, got %s", JobStatusFailed, job.Status)
+		}
+
+		if job.ExitCode.Int64 != 3 {
+			t.Fatalf("expected exit code 3, got %d", job.ExitCode.Int64)
+		}
+
+		if job.FailureReason.String != FailureReasonExitCode {
+			t.Fatalf("expected failure reason %s, got %s", FailureReasonExitCode, job.FailureReason.String)
+		}
+	})
+
+	t.Run("fails jobs that can not be launched", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		runtime.FailNextLaunch(errors.New("insufficient capacity"))
+
+		if _, err := ProcessJob(ctx, log, store, runtime, schedule.ID, ConcurrencyLimits{}); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if jobs[0].Status != JobStatusFailed || jobs[0].FailureReason.String != FailureReasonLaunchFailed {
+			t.Fatalf("expected a failed launch, got %s (%s)", jobs[0].Status, jobs[0].FailureReason.String)
+		}
+	})
+
+	t.Run("fails over to the next region", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		schedule.Regions = []string{"iad", "ord", "ewr"}
+		schedule.RegionStrategy = RegionStrategyOrderedFailover
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		runtime.FailNextLaunch(errors.New("insufficient capacity"))
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if job.Status != JobStatusRunning {
+			t.Fatalf("expected job to be %s, got %s", JobStatusRunning, job.Status)
+		}
+
+		if job.Region.String != "ord" {
+			t.Fatalf("expected job to run in ord, got %q", job.Region.String)
+		}
+
+		if len(job.LaunchAttempts) != 1 || job.LaunchAttempts[0].Region != "iad" {
+			t.Fatalf("expected a failed launch attempt in iad, got %+v", job.LaunchAttempts)
+		}
+	})
+
+	t.Run("does not fail over launches rejected everywhere", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		schedule.Regions = []string{"iad", "ord", "ewr"}
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		runtime.FailNextLaunch(fmt.Errorf("%w: invalid image", ErrLaunchRejected))
+
+		if _, err := ProcessJob(ctx, log, store, runtime, schedule.ID, ConcurrencyLimits{}); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if jobs[0].Status != JobStatusFailed || len(jobs[0].LaunchAttempts) != 1 {
+			t.Fatalf("expected the job to fail after a single attempt, got %s with %+v", jobs[0].Status, jobs[0].LaunchAttempts)
+		}
+	})
+
+	t.Run("reuses the schedule's stopped machine", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		schedule.MachineMode = MachineModeReuse
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		first := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(first.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := runtime.ExitMachine(first.MachineID.String, 0, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		first = pollTestJob(t, store, runtime, first.ID)
+		if first.Status != JobStatusCompleted {
+			t.Fatalf("expected job to be %s, got %s", JobStatusCompleted, first.Status)
+		}
+
+		// Changing the schedule should update the machine before it is started again.
+		schedule.Command = "uptime -p"
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		// Event timestamps have millisecond resolution, keep the jobs' events apart.
+		time.Sleep(2 * time.Millisecond)
+
+		second := triggerTestJob(t, store, runtime, schedule)
+		if second.MachineID.String != first.MachineID.String {
+			t.Fatalf("expected machine %s to be reused, got %s", first.MachineID.String, second.MachineID.String)
+		}
+
+		machine, err := runtime.MachineGet(ctx, second.MachineID.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cmd := machine.Config.Init.Cmd; len(cmd) != 2 || cmd[1] != "-p" {
+			t.Fatalf("expected machine config to be updated, got command %v", cmd)
+		}
+
+		// The previous job's exit event must not be attributed to the running job.
+		second = pollTestJob(t, store, runtime, second.ID)
+		if second.Status != JobStatusRunning {
+			t.Fatalf("expected job to be %s, got %s", JobStatusRunning, second.Status)
+		}
+
+		if err := runtime.ExitMachine(second.MachineID.String, 2, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		second = pollTestJob(t, store, runtime, second.ID)
+		if second.Status != JobStatusFailed || second.ExitCode.Int64 != 2 {
+			t.Fatalf("expected job to fail with exit code 2, got %s (%d)", second.Status, second.ExitCode.Int64)
+		}
+	})
+
+	t.Run("launches pinned images by digest", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		digest, err := runtime.ResolveImage(ctx, schedule.AppName, schedule.Config.Image)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		schedule.PinImage = true
+		schedule.PinnedImage = pinImage(schedule.Config.Image, digest)
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if job.ImageDigest.String != digest {
+			t.Fatalf("expected image digest %s, got %s", digest, job.ImageDigest.String)
+		}
+
+		machine, err := runtime.MachineGet(ctx, job.MachineID.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if expected := "ghcr.io/livebook-dev/livebook@" + digest; machine.Config.Image != expected {
+			t.Fatalf("expected machine to be launched with %s, got %s", expected, machine.Config.Image)
+		}
+	})
+
+	t.Run("reruns jobs with their original config", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		original := triggerTestJob(t, store, runtime, schedule)
+
+		// Changes to the schedule must not affect the rerun.
+		schedule.Command = "uptime -p"
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := RerunJob(ctx, log, store, runtime, original.ID, ConcurrencyLimits{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.RerunOf.Int64 != int64(original.ID) {
+			t.Fatalf("expected job to be a rerun of %d, got %d", original.ID, job.RerunOf.Int64)
+		}
+
+		machine, err := runtime.MachineGet(ctx, job.MachineID.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if cmd := machine.Config.Init.Cmd; len(cmd) != 1 || cmd[0] != "uptime" {
+			t.Fatalf("expected the original command to be run, got %v", cmd)
+		}
+	})
+
+	t.Run("reruns jobs of reuse schedules on a machine of their own", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		schedule.MachineMode = MachineModeReuse
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		original := triggerTestJob(t, store, runtime, schedule)
+
+		job, err := RerunJob(ctx, log, store, runtime, original.ID, ConcurrencyLimits{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.MachineID.String == original.MachineID.String {
+			t.Fatalf("expected the rerun to launch a new machine, got %s", job.MachineID.String)
+		}
+
+		machine, err := runtime.MachineGet(ctx, job.MachineID.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Apart from the launch mode, the snapshot is launched as is
+		if machine.Config.AutoDestroy {
+			t.Fatal("expected the snapshot's auto_destroy to be kept")
+		}
+
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := runtime.ExitMachine(job.MachineID.String, 0, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusCompleted {
+			t.Fatalf("expected job to be %s, got %s", JobStatusCompleted, job.Status)
+		}
+
+		if machine, err = runtime.MachineGet(ctx, job.MachineID.String); err != nil {
+			t.Fatal(err)
+		}
+
+		if machine.State != fly.MachineStateDestroyed {
+			t.Fatalf("expected the rerun's machine to be destroyed, got %s", machine.State)
+		}
+
+		record, err := store.FindScheduleMachine(ctx, schedule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if record.MachineID != original.MachineID.String {
+			t.Fatalf("expected the schedule to keep machine %s, got %s", original.MachineID.String, record.MachineID)
+		}
+	})
+
+	t.Run("stops jobs that exceed their timeout", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if !job.StopRequestedAt.Valid {
+			t.Fatal("expected a stop to be requested")
+		}
+
+		if signal := runtime.StopSignal(job.MachineID.String); signal != "SIGINT" {
+			t.Fatalf("expected machine to be stopped with SIGINT, got %q", signal)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusTimedOut {
+			t.Fatalf("expected job to be %s, got %s", JobStatusTimedOut, job.Status)
+		}
+
+		if job.FailureReason.String != FailureReasonCommandTimeout {
+			t.Fatalf("expected failure reason %s, got %s", FailureReasonCommandTimeout, job.FailureReason.String)
+		}
+	})
+
+	t.Run("destroys timed out jobs that ignore the stop signal", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		runtime.IgnoreStop(job.MachineID.String)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		// First poll requests the stop, the second finds the grace period has elapsed.
+		pollTestJob(t, store, runtime, job.ID)
+		job = pollTestJob(t, store, runtime, job.ID)
+
+		if job.Status != JobStatusTimedOut {
+			t.Fatalf("expected job to be %s, got %s", JobStatusTimedOut, job.Status)
+		}
+
+		machine, err := runtime.MachineGet(ctx, job.MachineID.String)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if machine.State != fly.MachineStateDestroyed {
+			t.Fatalf("expected machine to be destroyed, got %s", machine.State)
+		}
+
+		events, err := store.ListJobEvents(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorded := make(map[string]string)
+		for _, event := range events {
+			if event.Source == jobEventSourceCronManager {
+				recorded[event.Type] = event.Status
+			}
+		}
+
+		if recorded[JobEventStopRequested] != "SIGINT" {
+			t.Errorf("expected the stop request to be recorded with SIGINT, got %q", recorded[JobEventStopRequested])
+		}
+
+		for _, eventType := range []string{JobEventGraceElapsed, JobEventDestroyRequested} {
+			if _, ok := recorded[eventType]; !ok {
+				t.Errorf("expected a %s event to be recorded", eventType)
+			}
+		}
+	})
+
+	t.Run("marks jobs lost when their machine disappears", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		runtime.RemoveMachine(job.MachineID.String)
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusLost {
+			t.Fatalf("expected job to be %s, got %s", JobStatusLost, job.Status)
+		}
+	})
+
+	t.Run("marks jobs lost when their exit code is unknown", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := runtime.ExitMachineWithoutCode(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		job = pollTestJob(t, store, runtime, job.ID)
+		if job.Status != JobStatusLost || job.FailureReason.String != FailureReasonUnknownExit {
+			t.Fatalf("expected job to be %s with %s, got %s %s", JobStatusLost, FailureReasonUnknownExit, job.Status, job.FailureReason.String)
+		}
+	})
+
+	t.Run("skips apps that are rate limited", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := runtime.ExitMachine(job.MachineID.String, 0, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		runtime.FailList(ErrRateLimited)
+
+		monitor := NewMonitor(store, runtime, log)
+		if err := monitor.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, limited := monitor.rateLimited(schedule.AppName); !limited {
+			t.Fatal("expected app to be backed off")
+		}
+
+		job, err := store.FindJob(ctx, fmt.Sprint(job.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status != JobStatusRunning {
+			t.Fatalf("expected job to be %s, got %s", JobStatusRunning, job.Status)
+		}
+	})
+
+	t.Run("backs off apps rate limited while evaluating jobs", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		runtime.IgnoreStop(job.MachineID.String)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+
+		// The first poll requests the stop, the second is rate limited destroying the machine
+		pollTestJob(t, store, runtime, job.ID)
+		runtime.FailDestroy(ErrRateLimited)
+
+		monitor := NewMonitor(store, runtime, log)
+		if err := monitor.Poll(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, limited := monitor.rateLimited(schedule.AppName); !limited {
+			t.Fatal("expected app to be backed off")
+		}
+	})
+
+	t.Run("cancels running jobs", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := CancelJob(ctx, log, store, runtime, job.ID, "SIGUSR1", time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := store.FindJob(ctx, fmt.Sprint(job.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status != JobStatusCancelled {
+			t.Fatalf("expected job to be %s, got %s", JobStatusCancelled, job.Status)
+		}
+
+		if signal := runtime.StopSignal(job.MachineID.String); signal != "SIGUSR1" {
+			t.Fatalf("expected machine to be stopped with SIGUSR1, got %q", signal)
+		}
+	})
+
+	t.Run("does not cancel jobs that finish while being cancelled", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if err := runtime.StartMachine(job.MachineID.String, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		// The job completes after being looked up to be cancelled, but before its status is updated
+		if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		cancelled, err := store.CancelJob(ctx, job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cancelled {
+			t.Fatal("expected the finished job not to be cancelled")
+		}
+
+		if err := CancelJob(ctx, log, store, runtime, job.ID, "", 0); err == nil {
+			t.Fatal("expected cancelling the finished job to fail")
+		}
+
+		job, err = store.FindJob(ctx, fmt.Sprint(job.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status != JobStatusCompleted {
+			t.Fatalf("expected job to remain %s, got %s", JobStatusCompleted, job.Status)
+		}
+
+		if signal := runtime.StopSignal(job.MachineID.String); signal != "" {
+			t.Fatalf("expected the machine of the finished job not to be stopped, got %q", signal)
+		}
+	})
+
+	t.Run("does not finish cancelled jobs", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		job := triggerTestJob(t, store, runtime, schedule)
+
+		if err := CancelJob(ctx, log, store, runtime, job.ID, "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		// The monitor evaluated the job before it was cancelled
+		for _, finish := range []func() (bool, error){
+			func() (bool, error) { return store.CompleteJob(ctx, job.ID, 0, "") },
+			func() (bool, error) { return store.FailJob(ctx, job.ID, 1, FailureReasonExitCode, "") },
+			func() (bool, error) { return store.TimeoutJob(ctx, job.ID, "") },
+			func() (bool, error) { return store.LoseJob(ctx, job.ID, FailureReasonMachineNotFound, "") },
+		} {
+			finished, err := finish()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if finished {
+				t.Fatal("expected the cancelled job not to be finished")
+			}
+		}
+
+		assertQueueTestStatus(t, store, job.ID, JobStatusCancelled)
+	})
+
+	t.Run("cancels jobs while their machine is being launched", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		var launched *fly.Machine
+		runtime.OnProvision(func(machine *fly.Machine) {
+			launched = machine
+
+			jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The job has no machine recorded yet, so it's cancelled without one
+			if err := CancelJob(ctx, log, store, runtime, jobs[0].ID, "", 0); err != nil {
+				t.Fatal(err)
+			}
+		})
+
+		job := triggerTestJob(t, store, runtime, schedule)
+		if job.Status != JobStatusCancelled {
+			t.Fatalf("expected job to remain %s, got %s", JobStatusCancelled, job.Status)
+		}
+
+		machine, err := runtime.MachineGet(ctx, launched.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if machine.State != fly.MachineStateDestroyed {
+			t.Fatalf("expected machine to be destroyed, got %s", machine.State)
+		}
+	})
+}
+
+// setupJobTest initializes a store with the uptime-check schedule of my-app, changed by the options if any.
+func setupJobTest(t *testing.T, options ...func(*Schedule)) (*Store, *SimulatedRuntime, *Schedule) {
+	t.Helper()
+
+	store, err := InitializeStore(context.TODO(), filepath.Join(t.TempDir(), "state.db"), "../../migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	return store, NewSimulatedRuntime(), addTestSchedule(t, store, options...)
+}
+
+// addTestSchedule creates the schedule setupJobTest starts with, changed by the options, which must give it another
+// name if the store already has the schedule.
+func addTestSchedule(t *testing.T, store *Store, options ...func(*Schedule)) *Schedule {
+	t.Helper()
+
+	schedule := Schedule{
+		Name:           "uptime-check",
+		AppName:        "my-app",
+		Schedule:       "* * * * *",
+		Region:         "iad",
+		Command:        "uptime",
+		CommandTimeout: 30,
+		StopSignal:     "SIGINT",
+		Enabled:        true,
+		Config: fly.MachineConfig{
+			AutoDestroy: true,
+			Image:       "ghcr.io/livebook-dev/livebook:0.11.4",
+		},
+	}
+	for _, option := range options {
+		option(&schedule)
+	}
+
+	if err := store.CreateSchedule(context.TODO(), schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := store.FindScheduleByName(context.TODO(), schedule.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return created
+}
+
+func triggerTestJob(t *testing.T, store *Store, runtime *SimulatedRuntime, schedule *Schedule) *Job {
+	t.Helper()
+
+	job, err := ProcessJob(context.TODO(), logrus.New(), store, runtime, schedule.ID, ConcurrencyLimits{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
+
+// pollTestJob runs a single monitor pass and returns the refreshed job.
+func pollTestJob(t *testing.T, store *Store, runtime *SimulatedRuntime, jobID int) *Job {
+	t.Helper()
+
+	if err := NewMonitor(store, runtime, logrus.New()).Poll(context.TODO()); err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := store.FindJob(context.TODO(), fmt.Sprint(jobID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return job
+}
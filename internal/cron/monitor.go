@@ -2,8 +2,8 @@ package cron
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os/exec"
 	"sync"
 	"time"
 
@@ -13,133 +13,452 @@ import (
 
 const (
 	monitorFrequency = 5 * time.Second
+
+	// Rate limited apps are skipped for an exponentially increasing period.
+	minRateLimitBackoff = 5 * time.Second
+	maxRateLimitBackoff = 2 * time.Minute
 )
 
-// MonitorActiveJobs checks the status of all active jobs and updates their status.
-func MonitorActiveJobs(ctx context.Context, store *Store, log *logrus.Logger) error {
+// Monitor tracks running jobs and evaluates their Machines.
+// Running jobs are grouped by app so each app costs a single MachineList call per poll.
+type Monitor struct {
+	store    *Store
+	runtimes RuntimeProvider
+	log      *logrus.Logger
+
+	mu sync.Mutex
+	// backoff holds the time each rate limited app may be queried again, along with the last delay applied.
+	backoff      map[string]time.Time
+	backoffDelay map[string]time.Duration
+	// nextCheck holds the next time each job is due to be evaluated.
+	nextCheck map[int]time.Time
+
+	// retention is the global job retention policy, enforced periodically.
+	retention Retention
+	// watchdog alerts on schedules that stop running, it's nil when disabled.
+	watchdog *Watchdog
+	// limits cap the jobs launched from the launch queue as running jobs finish.
+	limits ConcurrencyLimits
+}
+
+func NewMonitor(store *Store, runtimes RuntimeProvider, log *logrus.Logger) *Monitor {
+	return &Monitor{
+		store:        store,
+		runtimes:     runtimes,
+		log:          log,
+		backoff:      make(map[string]time.Time),
+		backoffDelay: make(map[string]time.Duration),
+		nextCheck:    make(map[int]time.Time),
+	}
+}
+
+// MonitorActiveJobs checks the status of all active jobs and updates their status, launching queued jobs as they finish.
+// Job history is pruned according to the retention policies, and the watchdog checks schedules are running as well.
+func MonitorActiveJobs(ctx context.Context, store *Store, runtimes RuntimeProvider, log *logrus.Logger) error {
+	retention, err := RetentionFromEnv()
+	if err != nil {
+		return err
+	}
+
+	limits, err := ConcurrencyLimitsFromEnv()
+	if err != nil {
+		return err
+	}
+
+	watchdog, err := WatchdogFromEnv(store, log)
+	if err != nil {
+		return err
+	}
+
+	monitor := NewMonitor(store, runtimes, log)
+	monitor.retention = retention
+	monitor.watchdog = watchdog
+	monitor.limits = limits
+
+	return monitor.Run(ctx)
+}
+
+func (m *Monitor) Run(ctx context.Context) error {
 	ticker := time.NewTicker(monitorFrequency)
 	defer ticker.Stop()
 
+	pruneTicker := time.NewTicker(pruneFrequency)
+	defer pruneTicker.Stop()
+
+	watchdogTicker := time.NewTicker(watchdogFrequency)
+	defer watchdogTicker.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			// Find all active jobs
-			jobs, err := store.ListJobsByStatus(ctx, JobStatusRunning)
-			if err != nil {
-				return fmt.Errorf("failed to find active jobs: %w", err)
+			if err := m.Poll(ctx); err != nil {
+				return err
 			}
-			var wg sync.WaitGroup
-
-			// Loop through the running jobs and determine their status
-			for _, job := range jobs {
-				wg.Add(1)
-				go func(job Job) {
-					defer wg.Done()
-					if err := evaluateJob(ctx, log, store, job); err != nil {
-						log.WithError(err).Errorf("failed to monitor job %d", job.ID)
-					}
-				}(job)
-
+			if err := FailStalledLaunches(ctx, m.log, m.store, time.Now()); err != nil {
+				m.log.WithError(err).Error("failed to fail stalled launches")
+			}
+			if err := DispatchJobs(ctx, m.log, m.store, m.runtimes, m.limits); err != nil {
+				m.log.WithError(err).Error("failed to dispatch queued jobs")
+			}
+		case <-pruneTicker.C:
+			if _, err := PruneJobs(ctx, m.store, m.log, m.retention, false); err != nil {
+				m.log.WithError(err).Error("failed to prune jobs")
+			}
+			if err := m.store.PruneEvents(ctx, time.Now().Add(-eventRetention)); err != nil {
+				m.log.WithError(err).Error("failed to prune events")
+			}
+			if err := m.store.PrunePauses(ctx, time.Now()); err != nil {
+				m.log.WithError(err).Error("failed to prune expired pauses")
+			}
+		case <-watchdogTicker.C:
+			if m.watchdog == nil {
+				continue
+			}
+			if err := m.watchdog.Check(ctx, time.Now()); err != nil {
+				m.log.WithError(err).Error("failed to run watchdog")
 			}
-
-			wg.Wait()
 		}
 	}
 }
 
-func evaluateJob(ctx context.Context, logger *logrus.Logger, store *Store, job Job) error {
-	// Fetch the associated schedule for the job
-	schedule, err := store.FindSchedule(ctx, job.ScheduleID)
+// Poll evaluates every running job that is due for a check.
+func (m *Monitor) Poll(ctx context.Context) error {
+	// Find all active jobs
+	jobs, err := m.store.ListJobsByStatus(ctx, JobStatusRunning)
+	if err != nil {
+		return fmt.Errorf("failed to find active jobs: %w", err)
+	}
+
+	schedules, err := m.store.ListSchedules(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to find schedule for job %d: %w", job.ID, err)
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	schedulesByID := make(map[int]*Schedule, len(schedules))
+	for i := range schedules {
+		schedulesByID[schedules[i].ID] = &schedules[i]
+	}
+
+	now := time.Now()
+	jobsByApp := make(map[string][]Job)
+	active := make(map[int]bool, len(jobs))
+
+	for _, job := range jobs {
+		active[job.ID] = true
+
+		schedule, ok := schedulesByID[job.ScheduleID]
+		if !ok {
+			m.log.Errorf("failed to find schedule %d for job %d", job.ScheduleID, job.ID)
+			continue
+		}
+
+		if next, ok := m.nextCheck[job.ID]; ok && now.Before(next) {
+			continue
+		}
+
+		m.nextCheck[job.ID] = now.Add(pollInterval(job, schedule, now))
+		jobsByApp[schedule.AppName] = append(jobsByApp[schedule.AppName], job)
+	}
+
+	// Forget jobs that are no longer running
+	for id := range m.nextCheck {
+		if !active[id] {
+			delete(m.nextCheck, id)
+		}
 	}
 
-	log := logger.WithFields(logrus.Fields{
-		"app-name":   schedule.AppName,
-		"schedule":   schedule.Name,
-		"job-id":     job.ID,
-		"machine-id": job.MachineID.String,
-	})
+	var wg sync.WaitGroup
 
-	// Initialize the flaps client
-	client, err := NewFlapsClient(ctx, schedule.AppName, store)
+	for appName, appJobs := range jobsByApp {
+		wg.Add(1)
+		go func(appName string, appJobs []Job) {
+			defer wg.Done()
+			if err := m.pollApp(ctx, appName, appJobs, schedulesByID); err != nil {
+				m.log.WithError(err).WithField("app-name", appName).Error("failed to monitor app")
+			}
+		}(appName, appJobs)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (m *Monitor) pollApp(ctx context.Context, appName string, jobs []Job, schedules map[int]*Schedule) error {
+	if until, limited := m.rateLimited(appName); limited {
+		m.log.WithField("app-name", appName).Debugf("Skipping rate limited app until %s", until.Format(time.RFC3339))
+		return nil
+	}
+
+	runtime, err := m.runtimes.Runtime(ctx, appName)
 	if err != nil {
-		return fmt.Errorf("failed to create client for job %d: %w", job.ID, err)
+		return fmt.Errorf("failed to create runtime: %w", err)
 	}
 
-	// Fetch the machine associated with the job
-	machine, err := client.MachineGet(ctx, job.MachineID.String)
+	machines, err := runtime.MachineList(ctx, "")
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			if exitErr.ExitCode() == 404 {
-				// Machines are queryable up to 48 hours after they are destroyed.
-				// If the cron manager is shutdown or inactive for more than 48 hours, we will not be able to evaluate the result.
-				log.WithError(err).Errorf("failed to get machine %s: %v", job.MachineID.String, err)
-				if err := store.FailJob(ctx, job.ID, -1, "machine destroyed before we could interpret the results"); err != nil {
-					log.WithError(err).Errorf("failed to update job %d status", job.ID)
+		m.handleAPIError(appName, err)
+		return fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	managed := make(map[string]*fly.Machine)
+	for _, machine := range machines {
+		if machine.Config != nil && machine.Config.Metadata[managedByMetadataKey] == "true" {
+			managed[machine.ID] = machine
+		}
+	}
+
+	for _, job := range jobs {
+		schedule := schedules[job.ScheduleID]
+
+		log := m.log.WithFields(logrus.Fields{
+			"app-name":   schedule.AppName,
+			"schedule":   schedule.Name,
+			"job-id":     job.ID,
+			"machine-id": job.MachineID.String,
+		})
+
+		// Destroyed machines are not listed, and listed machines may omit their events.
+		// Fall back to fetching the machine directly in those cases.
+		machine, ok := managed[job.MachineID.String]
+		if !ok || len(machine.Events) == 0 {
+			machine, err = runtime.MachineGet(ctx, job.MachineID.String)
+			if err != nil {
+				if errors.Is(err, ErrMachineNotFound) {
+					// Machines are queryable up to 48 hours after they are destroyed.
+					// If the cron manager is shutdown or inactive for more than 48 hours, we will not be able to evaluate the result.
+					log.WithError(err).Warnf("machine %s could not be found", job.MachineID.String)
+					if _, err := m.store.LoseJob(ctx, job.ID, FailureReasonMachineNotFound, "machine destroyed before we could interpret the results"); err != nil {
+						log.WithError(err).Errorf("failed to update job %d status", job.ID)
+					}
+					continue
 				}
-				return nil
+
+				if m.handleAPIError(appName, err) {
+					return fmt.Errorf("rate limited while fetching machine %s: %w", job.MachineID.String, err)
+				}
+
+				log.WithError(err).Errorf("failed to get machine %s", job.MachineID.String)
+				continue
+			}
+		}
+
+		if schedule.MachineMode == MachineModeReuse {
+			machine = machineEventsSince(machine, job.CreatedAt)
+		}
+
+		if err := evaluateJob(ctx, log, m.store, runtime, schedule, job, machine); err != nil {
+			if m.handleAPIError(appName, err) {
+				return fmt.Errorf("rate limited while monitoring job %d: %w", job.ID, err)
 			}
-			log.WithError(err).Errorf("failed to get machine %s: %v", job.MachineID.String, err)
+
+			log.WithError(err).Errorf("failed to monitor job %d", job.ID)
 		}
 	}
 
+	// Only a poll that made it through every job without being rate limited clears the backoff
+	m.resetBackoff(appName)
+
+	return nil
+}
+
+func (m *Monitor) rateLimited(appName string) (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	until, ok := m.backoff[appName]
+	return until, ok && time.Now().Before(until)
+}
+
+// handleAPIError backs off the app when the error indicates we are being rate limited.
+func (m *Monitor) handleAPIError(appName string, err error) bool {
+	if !errors.Is(err, ErrRateLimited) {
+		return false
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delay := m.backoffDelay[appName] * 2
+	if delay < minRateLimitBackoff {
+		delay = minRateLimitBackoff
+	}
+	if delay > maxRateLimitBackoff {
+		delay = maxRateLimitBackoff
+	}
+
+	m.backoffDelay[appName] = delay
+	m.backoff[appName] = time.Now().Add(delay)
+
+	m.log.WithField("app-name", appName).Warnf("Rate limited by the machines API, backing off for %s", delay)
+
+	return true
+}
+
+func (m *Monitor) resetBackoff(appName string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.backoff, appName)
+	delete(m.backoffDelay, appName)
+}
+
+// pollInterval returns how long to wait before evaluating the job again.
+// Young jobs are checked frequently, long running jobs progressively less so, but
+// never later than the point their command timeout is reached.
+// Jobs are aged from when they left the launch queue, so time spent queued doesn't count.
+func pollInterval(job Job, schedule *Schedule, now time.Time) time.Duration {
+	launchedAt := job.CreatedAt
+	if job.DispatchedAt.Valid {
+		launchedAt = job.DispatchedAt.Time
+	}
+
+	age := now.Sub(launchedAt)
+
+	var interval time.Duration
+	switch {
+	case age < time.Minute:
+		interval = monitorFrequency
+	case age < 10*time.Minute:
+		interval = 15 * time.Second
+	case age < time.Hour:
+		interval = 30 * time.Second
+	default:
+		interval = time.Minute
+	}
+
+	untilTimeout := launchedAt.Add(time.Duration(schedule.CommandTimeout) * time.Second).Sub(now)
+	if job.StopRequestedAt.Valid {
+		untilTimeout = job.StopRequestedAt.Time.Add(time.Duration(schedule.KillTimeout) * time.Second).Sub(now)
+	}
+
+	if untilTimeout > 0 && untilTimeout < interval {
+		interval = untilTimeout
+	}
+
+	if interval < monitorFrequency {
+		interval = monitorFrequency
+	}
+
+	return interval
+}
+
+func evaluateJob(ctx context.Context, log *logrus.Entry, store *Store, runtime MachineRuntime, schedule *Schedule, job Job, machine *fly.Machine) error {
 	log.Debugf("Monitoring job")
 
+	if err := store.RecordJobEvents(ctx, convertMachineEvents(job.ID, machine.Events)); err != nil {
+		log.WithError(err).Warn("failed to record machine events")
+	}
+
+	if err := store.SetJobMachineSnapshot(ctx, job.ID, machine); err != nil {
+		log.WithError(err).Warn("failed to record machine snapshot")
+	}
+
 	startEvent := findEvent(machine, "start")
 	if startEvent == nil {
 		log.Debugf("Machine %s has not started yet", machine.ID)
 		return nil
 	}
 
-	switch machine.State {
-	case fly.MachineStateDestroyed:
-		log.Debugf("Machine %s is destroyed", machine.ID)
+	// Reused Machines are stopped rather than destroyed once the command exits.
+	reused := schedule.MachineMode == MachineModeReuse && machine.State == fly.MachineStateStopped
+
+	switch {
+	case machine.State == fly.MachineStateDestroyed, reused:
+		log.Debugf("Machine %s is %s", machine.ID, machine.State)
+
+		// Reruns of reuse schedules stop their own Machine, which isn't kept once the result is recorded
+		if reused && !isScheduleMachine(ctx, store, schedule, machine.ID) {
+			defer func() {
+				if err := runtime.MachineDestroy(ctx, machine); err != nil {
+					log.WithError(err).Warnf("failed to destroy machine %s", machine.ID)
+				}
+			}()
+		}
 
 		log = log.WithField("execution-time", fmt.Sprintf("%.2fs", calculateExecutionTime(machine)))
 
+		// The machine exited after being asked to stop, so the result is a timeout regardless of the exit code.
+		if job.StopRequestedAt.Valid {
+			log.Infof("Machine exited after receiving %s", schedule.StopSignal)
+			if _, err := store.TimeoutJob(ctx, job.ID, timeoutMessage(schedule, machine, false)); err != nil {
+				log.WithError(err).Errorf("failed to update job %d status", job.ID)
+			}
+			return nil
+		}
+
 		// Find the exit event
 		event := findEvent(machine, "exit")
 		if event == nil {
-			return fmt.Errorf("failed to find exit event for destroyed machine %s", machine.ID)
+			log.Warnf("Machine was %s without an exit event", machine.State)
+			if _, err := store.LoseJob(ctx, job.ID, FailureReasonMissingExit, fmt.Sprintf("machine %s without reporting an exit code", machine.State)); err != nil {
+				log.WithError(err).Errorf("failed to update job %d status", job.ID)
+			}
+			return nil
 		}
 
-		// Get the exit code
-		if event.Request != nil && event.Request.ExitEvent != nil {
-			exitCode := event.Request.ExitEvent.ExitCode
-			if exitCode != 0 {
-				if err := store.FailJob(ctx, job.ID, exitCode, ""); err != nil {
-					log.WithError(err).Errorf("failed to update job %d status", job.ID)
-				}
-				log.Infof("Job failed with exit code %d", exitCode)
-			} else {
-				if err := store.CompleteJob(ctx, job.ID, exitCode, ""); err != nil {
-					log.WithError(err).Errorf("failed to update job %d status", job.ID)
-				}
-				log.Infof("Job completed successfully")
+		stdout, stderr := machineOutput(ctx, log, runtime, machine)
+
+		// The exit event doesn't always carry the exit code, in which case the result can't be known
+		if event.Request == nil || event.Request.ExitEvent == nil {
+			log.Warn("Machine exited without reporting an exit code")
+			if _, err := store.LoseJob(ctx, job.ID, FailureReasonUnknownExit, stderr); err != nil {
+				log.WithError(err).Errorf("failed to update job %d status", job.ID)
 			}
+			return nil
+		}
+
+		exitCode := event.Request.ExitEvent.ExitCode
+		if exitCode != 0 {
+			if _, err := store.FailJob(ctx, job.ID, exitCode, FailureReasonExitCode, stderr); err != nil {
+				log.WithError(err).Errorf("failed to update job %d status", job.ID)
+			}
+			log.Infof("Job failed with exit code %d", exitCode)
+		} else {
+			if _, err := store.CompleteJob(ctx, job.ID, exitCode, stdout); err != nil {
+				log.WithError(err).Errorf("failed to update job %d status", job.ID)
+			}
+			log.Infof("Job completed successfully")
 		}
 	default:
 		executionTime := calculateExecutionTime(machine)
 		log = log.WithField("execution-time", fmt.Sprintf("%.2fs", executionTime))
 
+		// A stop has already been requested, allow the machine its grace period before destroying it.
+		if job.StopRequestedAt.Valid {
+			return enforceKillTimeout(ctx, log, store, runtime, schedule, job, machine)
+		}
+
 		// Machine is in a non-destroyed state, verify run time hasn't exceeded the command timeout
 		if executionTime > float64(schedule.CommandTimeout) {
-			err := fmt.Sprintf("machine `%s` exceeded the command timeout of %d seconds.",
-				machine.ID,
-				schedule.CommandTimeout)
+			log.Warnf("Machine exceeded the command timeout of %d seconds, stopping with %s", schedule.CommandTimeout, schedule.StopSignal)
+
+			killTimeout := time.Duration(schedule.KillTimeout) * time.Second
+			recordTimeoutEvent(ctx, log, store, job.ID, JobEventStopRequested, schedule.StopSignal)
+
+			if err := runtime.MachineStop(ctx, machine, schedule.StopSignal, killTimeout); err != nil {
+				log.WithError(err).Warn("failed to stop machine, destroying it")
 
-			log.Warn(err)
+				recordTimeoutEvent(ctx, log, store, job.ID, JobEventDestroyRequested, machine.State)
+				if err := runtime.MachineDestroy(ctx, machine); err != nil {
+					return fmt.Errorf("failed to destroy machine %s: %w", machine.ID, err)
+				}
+				snapshotMachine(ctx, log, store, runtime, job.ID, machine.ID)
 
-			if err := client.MachineDestroy(ctx, machine); err != nil {
-				return fmt.Errorf("failed to destroy machine %s: %w", machine.ID, err)
+				if _, err := store.TimeoutJob(ctx, job.ID, timeoutMessage(schedule, machine, true)); err != nil {
+					log.WithError(err).Errorf("failed to update job %d status", job.ID)
+				}
+				return nil
 			}
 
-			if err := store.FailJob(ctx, job.ID, -1, err); err != nil {
-				log.WithError(err).Errorf("failed to update job %d status", job.ID)
+			if err := store.RequestJobStop(ctx, job.ID); err != nil {
+				return fmt.Errorf("failed to record stop request for job %d: %w", job.ID, err)
 			}
+
+			log.Infof("Waiting up to %s for machine to stop", killTimeout)
+			return nil
 		}
 
 		log.Debugf("Machine is in state %s", machine.State)
@@ -148,6 +467,88 @@ func evaluateJob(ctx context.Context, logger *logrus.Logger, store *Store, job J
 	return nil
 }
 
+// enforceKillTimeout destroys a timed out machine once it has stopped, or once its grace period has elapsed.
+func enforceKillTimeout(ctx context.Context, log *logrus.Entry, store *Store, runtime MachineRuntime, schedule *Schedule, job Job, machine *fly.Machine) error {
+	killTimeout := time.Duration(schedule.KillTimeout) * time.Second
+	elapsed := time.Since(job.StopRequestedAt.Time)
+
+	stopped := machine.State == fly.MachineStateStopped
+	if !stopped && elapsed < killTimeout {
+		log.Debugf("Waiting for machine to stop, %s of %s grace period elapsed", elapsed.Round(time.Second), killTimeout)
+		return nil
+	}
+
+	if stopped {
+		log.Infof("Machine stopped after receiving %s, destroying it", schedule.StopSignal)
+	} else {
+		log.Warnf("Machine did not stop within the %s grace period, destroying it", killTimeout)
+		recordTimeoutEvent(ctx, log, store, job.ID, JobEventGraceElapsed, machine.State)
+	}
+
+	recordTimeoutEvent(ctx, log, store, job.ID, JobEventDestroyRequested, machine.State)
+	if err := runtime.MachineDestroy(ctx, machine); err != nil {
+		return fmt.Errorf("failed to destroy machine %s: %w", machine.ID, err)
+	}
+	snapshotMachine(ctx, log, store, runtime, job.ID, machine.ID)
+
+	if _, err := store.TimeoutJob(ctx, job.ID, timeoutMessage(schedule, machine, !stopped)); err != nil {
+		log.WithError(err).Errorf("failed to update job %d status", job.ID)
+	}
+
+	return nil
+}
+
+// recordTimeoutEvent records a phase of tearing down a timed out job's Machine on the job, so it's shown alongside
+// the Machine's own events.
+func recordTimeoutEvent(ctx context.Context, log *logrus.Entry, store *Store, jobID int, eventType, status string) {
+	event := JobEvent{
+		JobID:     jobID,
+		Type:      eventType,
+		Status:    status,
+		Source:    jobEventSourceCronManager,
+		Timestamp: time.Now(),
+	}
+	if err := store.RecordJobEvents(ctx, []JobEvent{event}); err != nil {
+		log.WithError(err).Warnf("failed to record %s event", eventType)
+	}
+}
+
+// snapshotMachine records the latest state of a Machine the job will no longer be evaluated against.
+func snapshotMachine(ctx context.Context, log *logrus.Entry, store *Store, runtime MachineRuntime, jobID int, machineID string) {
+	machine, err := runtime.MachineGet(ctx, machineID)
+	if err != nil {
+		log.WithError(err).Warn("failed to get machine for snapshot")
+		return
+	}
+
+	if err := store.SetJobMachineSnapshot(ctx, jobID, machine); err != nil {
+		log.WithError(err).Warn("failed to record machine snapshot")
+	}
+}
+
+// machineOutput returns the captured output of the command for runtimes that are able to report it.
+func machineOutput(ctx context.Context, log *logrus.Entry, runtime MachineRuntime, machine *fly.Machine) (string, string) {
+	reader, ok := runtime.(OutputReader)
+	if !ok {
+		return "", ""
+	}
+
+	stdout, stderr, err := reader.MachineOutput(ctx, machine.ID)
+	if err != nil {
+		log.WithError(err).Warn("failed to read machine output")
+	}
+
+	return stdout, stderr
+}
+
+func timeoutMessage(schedule *Schedule, machine *fly.Machine, killed bool) string {
+	msg := fmt.Sprintf("machine `%s` exceeded the command timeout of %d seconds", machine.ID, schedule.CommandTimeout)
+	if killed {
+		return fmt.Sprintf("%s and was destroyed after failing to stop within %d seconds of %s.", msg, schedule.KillTimeout, schedule.StopSignal)
+	}
+	return fmt.Sprintf("%s and exited after receiving %s.", msg, schedule.StopSignal)
+}
+
 func calculateExecutionTime(machine *fly.Machine) float64 {
 	// Base it off the time the machine entered a start state.
 	startEvent := findEvent(machine, "start")
@@ -168,6 +569,23 @@ func calculateExecutionTime(machine *fly.Machine) float64 {
 	return endTime.Sub(startTime).Seconds()
 }
 
+// machineEventsSince returns a copy of the machine with only the events that occurred since the specified time.
+// Reused Machines retain the events of previous jobs, which must not be attributed to the current job.
+func machineEventsSince(machine *fly.Machine, since time.Time) *fly.Machine {
+	// Event timestamps have millisecond resolution
+	since = since.Truncate(time.Millisecond)
+
+	m := *machine
+	m.Events = nil
+	for _, event := range machine.Events {
+		if !event.Time().Before(since) {
+			m.Events = append(m.Events, event)
+		}
+	}
+
+	return &m
+}
+
 func findEvent(machine *fly.Machine, eventType string) *fly.MachineEvent {
 	if len(machine.Events) == 0 {
 		return nil
@@ -0,0 +1,61 @@
+package cron
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestPollInterval(t *testing.T) {
+	now := time.Now()
+	schedule := &Schedule{CommandTimeout: 3600, KillTimeout: 10}
+
+	tests := []struct {
+		name     string
+		job      Job
+		expected time.Duration
+	}{
+		{
+			name:     "young job",
+			job:      Job{CreatedAt: now.Add(-30 * time.Second)},
+			expected: monitorFrequency,
+		},
+		{
+			name:     "long running job",
+			job:      Job{CreatedAt: now.Add(-2 * time.Hour)},
+			expected: time.Minute,
+		},
+		{
+			name: "job queued long ago and dispatched just now",
+			job: Job{
+				CreatedAt:    now.Add(-2 * time.Hour),
+				DispatchedAt: sql.NullTime{Time: now.Add(-30 * time.Second), Valid: true},
+			},
+			expected: monitorFrequency,
+		},
+		{
+			name: "job approaching its timeout",
+			job: Job{
+				CreatedAt:    now.Add(-3 * time.Hour),
+				DispatchedAt: sql.NullTime{Time: now.Add(-3580 * time.Second), Valid: true},
+			},
+			expected: 20 * time.Second,
+		},
+		{
+			name: "job asked to stop",
+			job: Job{
+				CreatedAt:       now.Add(-2 * time.Hour),
+				StopRequestedAt: sql.NullTime{Time: now, Valid: true},
+			},
+			expected: 10 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if interval := pollInterval(tt.job, schedule, now); interval != tt.expected {
+				t.Errorf("expected an interval of %s, got %s", tt.expected, interval)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// AlertNotification is sent to notifiers when a schedule alert is raised or resolved.
+type AlertNotification struct {
+	ScheduleAlert
+	ScheduleName string `json:"schedule_name"`
+	Resolved     bool   `json:"resolved"`
+}
+
+// Notifier is notified of the watchdog's alerts.
+type Notifier interface {
+	Notify(ctx context.Context, notification AlertNotification) error
+}
+
+// LogNotifier logs alerts as warnings, and their resolution as info.
+type LogNotifier struct {
+	log *logrus.Logger
+}
+
+func NewLogNotifier(log *logrus.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	log := n.log.WithFields(logrus.Fields{
+		"schedule": notification.ScheduleName,
+		"alert":    notification.Kind,
+	})
+
+	if notification.Resolved {
+		log.Infof("Resolved alert: %s", notification.Message)
+		return nil
+	}
+
+	log.Warnf("Alert: %s", notification.Message)
+	return nil
+}
+
+// WebhookNotifier posts alerts as JSON to a URL.
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:    url,
+		client: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post alert: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to post alert: webhook responded with %s", resp.Status)
+	}
+
+	return nil
+}
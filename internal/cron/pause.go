@@ -0,0 +1,154 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Pause skips the scheduled runs of a schedule, the schedules of an app, or every schedule when neither is set,
+// until it's resumed or until it expires. Jobs triggered by hand are never paused.
+type Pause struct {
+	ID           int    `json:"id" db:"id"`
+	ScheduleName string `json:"schedule_name" db:"schedule_name"`
+	AppName      string `json:"app_name" db:"app_name"`
+	Reason       string `json:"reason" db:"reason"`
+	// Until is when the pause expires, it lasts until it's resumed when not set.
+	Until     sql.NullTime `json:"until" db:"until"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// Matches reports whether the pause applies to the schedule.
+func (p Pause) Matches(schedule Schedule) bool {
+	return (p.ScheduleName == "" || p.ScheduleName == schedule.Name) && (p.AppName == "" || p.AppName == schedule.AppName)
+}
+
+// Scope describes what the pause applies to.
+func (p Pause) Scope() string {
+	switch {
+	case p.ScheduleName != "":
+		return fmt.Sprintf("schedule %s", p.ScheduleName)
+	case p.AppName != "":
+		return fmt.Sprintf("app %s", p.AppName)
+	default:
+		return "all schedules"
+	}
+}
+
+// PauseSchedules pauses the scheduled runs of the schedule, app or every schedule described by the pause.
+func PauseSchedules(ctx context.Context, store *Store, pause Pause) (*Pause, error) {
+	if pause.ScheduleName != "" && pause.AppName != "" {
+		return nil, fmt.Errorf("a pause applies to either a schedule or an app, not both")
+	}
+
+	if pause.Until.Valid && !pause.Until.Time.After(time.Now()) {
+		return nil, fmt.Errorf("until must be in the future")
+	}
+
+	if pause.ScheduleName != "" {
+		if _, err := store.FindScheduleByName(ctx, pause.ScheduleName); err != nil {
+			return nil, fmt.Errorf("failed to find schedule %s: %w", pause.ScheduleName, err)
+		}
+	}
+
+	return store.CreatePause(ctx, pause)
+}
+
+// BlackoutWindow is a period during which a schedule's runs are skipped. A window is either fixed, between Start and
+// End, or recurring, starting each time the Cron expression fires and lasting for the Duration.
+type BlackoutWindow struct {
+	// Start and End are RFC 3339 timestamps.
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+	// Cron is evaluated in UTC, Duration is a duration such as 2h, days may be specified as e.g. 1d.
+	Cron     string `json:"cron,omitempty"`
+	Duration string `json:"duration,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func (w BlackoutWindow) Validate() error {
+	_, err := w.Contains(time.Now())
+	return err
+}
+
+// Contains reports whether the time falls within the window.
+func (w BlackoutWindow) Contains(t time.Time) (bool, error) {
+	if w.Cron != "" {
+		if w.Start != "" || w.End != "" {
+			return false, fmt.Errorf("a window has either a cron expression or a start and end")
+		}
+
+		expr, err := ParseCronExpression(w.Cron)
+		if err != nil {
+			return false, fmt.Errorf("invalid cron %q: %w", w.Cron, err)
+		}
+
+		duration, err := ParseDuration(w.Duration)
+		if err != nil || duration <= 0 {
+			return false, fmt.Errorf("invalid duration %q", w.Duration)
+		}
+
+		// The window contains t if it last started within the duration before t
+		t = t.UTC()
+		start := expr.Next(t.Add(-duration))
+		return !start.IsZero() && !start.After(t), nil
+	}
+
+	start, err := time.Parse(time.RFC3339, w.Start)
+	if err != nil {
+		return false, fmt.Errorf("invalid start %q: %w", w.Start, err)
+	}
+
+	end, err := time.Parse(time.RFC3339, w.End)
+	if err != nil {
+		return false, fmt.Errorf("invalid end %q: %w", w.End, err)
+	}
+
+	if !end.After(start) {
+		return false, fmt.Errorf("end %q must be after start %q", w.End, w.Start)
+	}
+
+	return !t.Before(start) && t.Before(end), nil
+}
+
+// skipReason returns why the schedule's scheduled run at the time is skipped, along with a message describing it.
+// The reason is empty when the run should go ahead.
+func skipReason(ctx context.Context, store *Store, schedule Schedule, at time.Time) (string, string, error) {
+	pauses, err := store.ListPauses(ctx, at)
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, pause := range pauses {
+		if !pause.Matches(schedule) {
+			continue
+		}
+
+		message := fmt.Sprintf("%s paused", pause.Scope())
+		if pause.Until.Valid {
+			message += fmt.Sprintf(" until %s", pause.Until.Time.UTC().Format(time.RFC3339))
+		}
+		if pause.Reason != "" {
+			message += ": " + pause.Reason
+		}
+
+		return SkipReasonPaused, message, nil
+	}
+
+	for _, window := range schedule.Blackout {
+		// Windows are validated when schedules are synced
+		if ok, err := window.Contains(at); err != nil || !ok {
+			continue
+		}
+
+		message := "schedule is within a blackout window"
+		if window.Reason != "" {
+			message += ": " + window.Reason
+		}
+
+		return SkipReasonBlackout, message, nil
+	}
+
+	return "", "", nil
+}
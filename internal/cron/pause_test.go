@@ -0,0 +1,226 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBlackoutWindow(t *testing.T) {
+	fixed := BlackoutWindow{Start: "2026-12-24T00:00:00Z", End: "2026-12-27T00:00:00Z"}
+
+	for at, expected := range map[string]bool{
+		"2026-12-23T23:59:59Z": false,
+		"2026-12-24T00:00:00Z": true,
+		"2026-12-25T12:00:00Z": true,
+		"2026-12-27T00:00:00Z": false,
+	} {
+		assertBlackoutContains(t, fixed, at, expected)
+	}
+
+	// Sundays from 02:00 to 05:00 UTC
+	recurring := BlackoutWindow{Cron: "0 2 * * 0", Duration: "3h"}
+
+	for at, expected := range map[string]bool{
+		"2026-10-18T01:59:00Z": false,
+		"2026-10-18T02:00:00Z": true,
+		"2026-10-18T04:59:00Z": true,
+		"2026-10-18T05:00:00Z": false,
+		"2026-10-19T03:00:00Z": false,
+	} {
+		assertBlackoutContains(t, recurring, at, expected)
+	}
+
+	for _, invalid := range []BlackoutWindow{
+		{},
+		{Start: "2026-12-24T00:00:00Z"},
+		{Start: "2026-12-27T00:00:00Z", End: "2026-12-24T00:00:00Z"},
+		{Cron: "0 2 * * 0"},
+		{Cron: "0 2 * * 0", Duration: "-1h"},
+		{Cron: "whenever", Duration: "1h"},
+		{Cron: "0 2 * * 0", Duration: "1h", Start: "2026-12-24T00:00:00Z"},
+	} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("expected window %+v to be invalid", invalid)
+		}
+	}
+}
+
+func assertBlackoutContains(t *testing.T, window BlackoutWindow, at string, expected bool) {
+	t.Helper()
+
+	ts, err := time.Parse(time.RFC3339, at)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := window.Contains(ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok != expected {
+		t.Errorf("expected window %+v to contain %s: %t, got %t", window, at, expected, ok)
+	}
+}
+
+func TestPauseSchedules(t *testing.T) {
+	ctx := context.TODO()
+	store, _, _ := setupJobTest(t)
+
+	for _, invalid := range []Pause{
+		{ScheduleName: "uptime-check", AppName: "my-app"},
+		{ScheduleName: "unknown"},
+		{Until: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}},
+	} {
+		if _, err := PauseSchedules(ctx, store, invalid); err == nil {
+			t.Errorf("expected pause %+v to be rejected", invalid)
+		}
+	}
+
+	for _, pause := range []Pause{{ScheduleName: "uptime-check"}, {AppName: "my-app"}, {}} {
+		if _, err := PauseSchedules(ctx, store, pause); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Resuming the app leaves the pauses of the schedule and of every schedule in place
+	n, err := store.DeletePauses(ctx, "", "my-app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 pause to be resumed, got %d", n)
+	}
+
+	pauses, err := store.ListPauses(ctx, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pauses) != 2 {
+		t.Errorf("expected 2 pauses to remain, got %d", len(pauses))
+	}
+}
+
+func TestSkipScheduledJob(t *testing.T) {
+	ctx := context.TODO()
+	log := logrus.New()
+
+	tests := []struct {
+		name     string
+		pause    *Pause
+		blackout []BlackoutWindow
+		reason   string
+		message  string
+	}{
+		{name: "not paused"},
+		{name: "schedule paused", pause: &Pause{ScheduleName: "uptime-check", Reason: "migration"}, reason: SkipReasonPaused, message: "schedule uptime-check paused: migration"},
+		{name: "app paused", pause: &Pause{AppName: "my-app"}, reason: SkipReasonPaused, message: "app my-app paused"},
+		{name: "everything paused", pause: &Pause{}, reason: SkipReasonPaused, message: "all schedules paused"},
+		{name: "other app paused", pause: &Pause{AppName: "other-app"}},
+		{
+			name:  "pause expired",
+			pause: &Pause{Until: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}},
+		},
+		{
+			name: "within blackout",
+			blackout: []BlackoutWindow{{
+				Start:  time.Now().Add(-time.Hour).UTC().Format(time.RFC3339),
+				End:    time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+				Reason: "freeze",
+			}},
+			reason:  SkipReasonBlackout,
+			message: "schedule is within a blackout window: freeze",
+		},
+		{
+			name: "outside blackout",
+			blackout: []BlackoutWindow{{
+				Start: time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+				End:   time.Now().Add(2 * time.Hour).UTC().Format(time.RFC3339),
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, runtime, schedule := setupJobTest(t)
+
+			if tt.pause != nil {
+				if _, err := store.CreatePause(ctx, *tt.pause); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if tt.blackout != nil {
+				schedule.Blackout = tt.blackout
+				if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			job, err := ProcessScheduledJob(ctx, log, store, runtime, schedule.ID, 0, ConcurrencyLimits{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.reason == "" {
+				if job.Status != JobStatusRunning {
+					t.Fatalf("expected job to be launched, got %s", job.Status)
+				}
+				return
+			}
+
+			if job.Status != JobStatusSkipped || job.MachineID.Valid || !job.FinishedAt.Valid {
+				t.Fatalf("expected job to be skipped without launching a machine, got %s", job.Status)
+			}
+
+			if job.FailureReason.String != tt.reason {
+				t.Errorf("expected reason %s, got %s", tt.reason, job.FailureReason.String)
+			}
+
+			if job.Stderr.String != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, job.Stderr.String)
+			}
+
+			// Jobs triggered by hand aren't skipped
+			manual := triggerTestJob(t, store, runtime, schedule)
+			if manual.Status != JobStatusRunning {
+				t.Errorf("expected a manually triggered job to be launched, got %s", manual.Status)
+			}
+		})
+	}
+
+	t.Run("paused while queued", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+		limits := ConcurrencyLimits{MaxConcurrentJobs: 1}
+
+		running := triggerTestJob(t, store, runtime, schedule)
+
+		scheduled, err := ProcessScheduledJob(ctx, log, store, runtime, schedule.ID, 0, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		manual, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CreatePause(ctx, Pause{ScheduleName: "uptime-check"}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CompleteJob(ctx, running.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		// The scheduled job is skipped rather than launched, leaving the slot to the job triggered by hand
+		assertQueueTestStatus(t, store, scheduled.ID, JobStatusSkipped)
+		assertQueueTestStatus(t, store, manual.ID, JobStatusRunning)
+	})
+}
@@ -0,0 +1,264 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// launchDeadline is how long a dispatched job may go without a Machine before its launch is considered interrupted.
+const launchDeadline = 10 * time.Minute
+
+// ConcurrencyLimits caps the number of jobs being launched or running at once. Jobs triggered beyond the limits
+// wait in the launch queue until running jobs finish. A limit of 0 is unlimited.
+type ConcurrencyLimits struct {
+	MaxConcurrentJobs int
+	// MaxConcurrentJobsPerApp limits the jobs of each app, apps that aren't listed fall back to DefaultPerApp.
+	MaxConcurrentJobsPerApp map[string]int
+	DefaultPerApp           int
+}
+
+// ConcurrencyLimitsFromEnv returns the limits set with MAX_CONCURRENT_JOBS and MAX_CONCURRENT_JOBS_PER_APP.
+// The per-app limits are a comma separated list of app=limit pairs, along with an optional limit for every
+// other app, e.g. "2,reports=1,billing=5". Jobs are unlimited unless they're set.
+func ConcurrencyLimitsFromEnv() (ConcurrencyLimits, error) {
+	var limits ConcurrencyLimits
+
+	if value := getEnvOrDefault("MAX_CONCURRENT_JOBS", ""); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return limits, fmt.Errorf("invalid MAX_CONCURRENT_JOBS %q", value)
+		}
+		limits.MaxConcurrentJobs = n
+	}
+
+	for _, entry := range strings.Split(getEnvOrDefault("MAX_CONCURRENT_JOBS_PER_APP", ""), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		app, value, found := strings.Cut(entry, "=")
+		if !found {
+			value = entry
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || n < 0 {
+			return limits, fmt.Errorf("invalid MAX_CONCURRENT_JOBS_PER_APP entry %q", entry)
+		}
+
+		if !found {
+			limits.DefaultPerApp = n
+			continue
+		}
+
+		if limits.MaxConcurrentJobsPerApp == nil {
+			limits.MaxConcurrentJobsPerApp = make(map[string]int)
+		}
+		limits.MaxConcurrentJobsPerApp[strings.TrimSpace(app)] = n
+	}
+
+	return limits, nil
+}
+
+// appLimit returns the limit on the app's jobs.
+func (l ConcurrencyLimits) appLimit(appName string) int {
+	if n, ok := l.MaxConcurrentJobsPerApp[appName]; ok {
+		return n
+	}
+	return l.DefaultPerApp
+}
+
+// DispatchJobs launches jobs from the launch queue in order, for as long as the limits allow.
+// Jobs of an app at its limit are passed over, so they don't hold up the jobs of other apps.
+func DispatchJobs(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, limits ConcurrencyLimits) error {
+	jobs, err := store.ListQueuedJobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	schedules := make(map[int]*Schedule)
+
+	for _, job := range jobs {
+		schedule, ok := schedules[job.ScheduleID]
+		if !ok {
+			schedule, err = store.FindSchedule(ctx, job.ScheduleID)
+			if err != nil {
+				log.WithError(err).Errorf("failed to find schedule of job %d", job.ID)
+				continue
+			}
+			schedules[job.ScheduleID] = schedule
+		}
+
+		// The schedule may have been paused, or entered a blackout window, while the job was queued
+		if job.Scheduled {
+			skipped, err := skipScheduledJob(ctx, log, store, schedule, &job)
+			if err != nil {
+				return err
+			}
+			if skipped {
+				continue
+			}
+		}
+
+		dispatched, err := dispatchJob(ctx, log, store, limits, schedule, job)
+		if err != nil {
+			return err
+		}
+
+		if dispatched {
+			// A job that fails to launch is failed with the reason, the rest of the queue is still dispatched
+			_ = launchQueuedJob(ctx, log, store, runtimes, job)
+		}
+	}
+
+	return nil
+}
+
+// dispatchJob takes a slot for the queued job if the limits allow, after which its Machine must be launched.
+func dispatchJob(ctx context.Context, log *logrus.Logger, store *Store, limits ConcurrencyLimits, schedule *Schedule, job Job) (bool, error) {
+	dispatched, err := store.DispatchJob(ctx, job.ID, schedule.AppName, limits.MaxConcurrentJobs, limits.appLimit(schedule.AppName))
+	if err != nil {
+		return false, err
+	}
+
+	if !dispatched {
+		log.WithFields(logrus.Fields{
+			"app-name": schedule.AppName,
+			"schedule": schedule.Name,
+			"job-id":   job.ID,
+		}).Debug("Job remains queued, concurrency limit reached")
+	}
+
+	return dispatched, nil
+}
+
+// FailStalledLaunches fails the jobs dispatched more than launchDeadline ago that were never given a Machine.
+// Jobs are dispatched before their Machine is launched, so a launch interrupted by a crash or restart leaves
+// the job pending, counting against the concurrency limits forever. The Machine may exist without being
+// recorded, so the job is failed rather than launched again.
+func FailStalledLaunches(ctx context.Context, log *logrus.Logger, store *Store, now time.Time) error {
+	jobs, err := store.ListStalledLaunches(ctx, now.Add(-launchDeadline))
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		failed, err := store.FailStalledLaunch(ctx, job.ID, fmt.Sprintf("job was dispatched at %s but its machine was never launched", job.DispatchedAt.Time.Format(time.RFC3339)))
+		if err != nil {
+			return fmt.Errorf("failed to fail job %d: %w", job.ID, err)
+		}
+
+		if failed {
+			log.WithField("job-id", job.ID).Warn("Failed job, its launch was interrupted")
+		}
+	}
+
+	return nil
+}
+
+// queueJob adds the job to the launch queue and launches it if it's next in line and the limits allow. Only the job
+// itself is launched, the rest of the queue is left to the monitor, which dispatches it in the background. The job is
+// returned as it stands after the launch, which is still pending if it remains queued, along with its launch error if
+// it failed to launch. The launch isn't cancelled with ctx, so a job is never left dispatched without its Machine.
+func queueJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, limits ConcurrencyLimits, job *Job) (*Job, error) {
+	if err := store.QueueJob(ctx, job.ID); err != nil {
+		return nil, fmt.Errorf("failed to queue job: %w", err)
+	}
+
+	schedule, err := store.FindSchedule(ctx, job.ScheduleID)
+	if err != nil {
+		return nil, err
+	}
+
+	next, err := nextInQueue(ctx, store, limits, schedule, job.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queued jobs: %w", err)
+	}
+
+	if next {
+		ctx = context.WithoutCancel(ctx)
+
+		dispatched, err := dispatchJob(ctx, log, store, limits, schedule, *job)
+		if err != nil {
+			return nil, fmt.Errorf("failed to dispatch job: %w", err)
+		}
+
+		if dispatched {
+			if err := launchQueuedJob(ctx, log, store, runtimes, *job); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return store.FindJob(ctx, fmt.Sprint(job.ID))
+}
+
+// nextInQueue reports whether no job queued ahead of the job competes with it for a slot. Jobs of other apps only
+// compete under the global limit. A job delayed by its jitter isn't in line until its delay has passed.
+func nextInQueue(ctx context.Context, store *Store, limits ConcurrencyLimits, schedule *Schedule, jobID int) (bool, error) {
+	jobs, err := store.ListQueuedJobs(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	apps := map[int]string{schedule.ID: schedule.AppName}
+
+	for _, job := range jobs {
+		if job.ID == jobID {
+			return true, nil
+		}
+
+		if limits.MaxConcurrentJobs > 0 {
+			return false, nil
+		}
+
+		app, ok := apps[job.ScheduleID]
+		if !ok {
+			ahead, err := store.FindSchedule(ctx, job.ScheduleID)
+			if err != nil {
+				return false, err
+			}
+			app = ahead.AppName
+			apps[job.ScheduleID] = app
+		}
+
+		if app == schedule.AppName {
+			return false, nil
+		}
+	}
+
+	return false, nil
+}
+
+// launchQueuedJob launches a dispatched job, with the Machine config of the job it re-runs if it's a rerun.
+func launchQueuedJob(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, job Job) error {
+	var (
+		schedule *Schedule
+		err      error
+	)
+
+	if job.RerunOf.Valid {
+		schedule, err = rerunSchedule(ctx, store, int(job.RerunOf.Int64))
+	} else {
+		schedule, err = store.FindSchedule(ctx, job.ScheduleID)
+		if err == nil {
+			err = prepareJob(schedule)
+		}
+	}
+
+	if err != nil {
+		err = fmt.Errorf("failed to prepare job: %w", err)
+		if _, failErr := store.FailJob(ctx, job.ID, 1, FailureReasonLaunchFailed, err.Error()); failErr != nil {
+			log.WithError(failErr).Errorf("failed to update job %d status", job.ID)
+		}
+		return err
+	}
+
+	return launchJob(ctx, log, store, runtimes, schedule, &job)
+}
@@ -0,0 +1,292 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestConcurrencyLimitsFromEnv(t *testing.T) {
+	t.Setenv("MAX_CONCURRENT_JOBS", "10")
+	t.Setenv("MAX_CONCURRENT_JOBS_PER_APP", "2, reports=1,billing=5")
+
+	limits, err := ConcurrencyLimitsFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if limits.MaxConcurrentJobs != 10 {
+		t.Errorf("expected a global limit of 10, got %d", limits.MaxConcurrentJobs)
+	}
+
+	for app, expected := range map[string]int{"reports": 1, "billing": 5, "other": 2} {
+		if n := limits.appLimit(app); n != expected {
+			t.Errorf("expected a limit of %d for %s, got %d", expected, app, n)
+		}
+	}
+
+	for _, invalid := range []string{"many", "reports=-1", "reports="} {
+		t.Setenv("MAX_CONCURRENT_JOBS_PER_APP", invalid)
+		if _, err := ConcurrencyLimitsFromEnv(); err == nil {
+			t.Errorf("expected %q to be invalid", invalid)
+		}
+	}
+}
+
+func TestLaunchQueue(t *testing.T) {
+	ctx := context.TODO()
+	log := logrus.New()
+
+	t.Run("queues jobs beyond the global limit", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+		urgent := addTestSchedule(t, store, queueTestSchedule("urgent-check", "my-app", 10))
+
+		limits := ConcurrencyLimits{MaxConcurrentJobs: 1}
+
+		running, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if running.Status != JobStatusRunning {
+			t.Fatalf("expected the first job to be launched, got %s", running.Status)
+		}
+
+		first, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := ProcessJob(ctx, log, store, runtime, urgent.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, job := range []*Job{first, second} {
+			if job.Status != JobStatusPending || job.MachineID.Valid || !job.QueuedAt.Valid || job.DispatchedAt.Valid {
+				t.Fatalf("expected job %d to be queued, got %s", job.ID, job.Status)
+			}
+		}
+
+		if _, err := store.CompleteJob(ctx, running.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		// The job of the schedule with the higher priority is launched first, despite being queued last
+		assertQueueTestStatus(t, store, second.ID, JobStatusRunning)
+		assertQueueTestStatus(t, store, first.ID, JobStatusPending)
+
+		if _, err := store.CompleteJob(ctx, second.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		assertQueueTestStatus(t, store, first.ID, JobStatusRunning)
+	})
+
+	t.Run("limits each app separately", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+		other := addTestSchedule(t, store, queueTestSchedule("other-check", "other-app", 0))
+
+		limits := ConcurrencyLimits{MaxConcurrentJobsPerApp: map[string]int{"my-app": 1}}
+
+		if _, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		queued, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if queued.Status != JobStatusPending {
+			t.Fatalf("expected job to be queued, got %s", queued.Status)
+		}
+
+		// Jobs of other apps aren't held up by the queued job
+		job, err := ProcessJob(ctx, log, store, runtime, other.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != JobStatusRunning {
+			t.Fatalf("expected job of another app to be launched, got %s", job.Status)
+		}
+	})
+
+	t.Run("launches only the triggered job", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+		other := addTestSchedule(t, store, queueTestSchedule("other-check", "other-app", 0))
+
+		limits := ConcurrencyLimits{MaxConcurrentJobsPerApp: map[string]int{"my-app": 1}}
+
+		running := triggerTestJob(t, store, runtime, schedule)
+
+		queued, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CompleteJob(ctx, running.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		// The job queued before the slot was freed is left to the monitor
+		if _, err := ProcessJob(ctx, log, store, runtime, other.ID, limits); err != nil {
+			t.Fatal(err)
+		}
+		assertQueueTestStatus(t, store, queued.ID, JobStatusPending)
+
+		// A job triggered behind it, for the same slot, waits its turn rather than taking the slot
+		behind, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertQueueTestStatus(t, store, behind.ID, JobStatusPending)
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		assertQueueTestStatus(t, store, queued.ID, JobStatusRunning)
+		assertQueueTestStatus(t, store, behind.ID, JobStatusPending)
+	})
+
+	t.Run("launches the triggered job when the trigger is cancelled", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		// The client disconnects once the job is dispatched, as its Machine is being launched
+		triggerCtx, cancel := context.WithCancel(ctx)
+		runtimes := cancellingRuntimeProvider{runtime: runtime, cancel: cancel}
+
+		job, err := ProcessJob(triggerCtx, log, store, runtimes, schedule.ID, ConcurrencyLimits{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if job.Status != JobStatusRunning || !job.MachineID.Valid {
+			t.Fatalf("expected the job to be launched, got %s", job.Status)
+		}
+	})
+
+	t.Run("does not launch cancelled jobs", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+
+		limits := ConcurrencyLimits{MaxConcurrentJobs: 1}
+
+		running := triggerTestJob(t, store, runtime, schedule)
+
+		queued, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := CancelJob(ctx, log, store, runtime, queued.ID, "", 0); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CompleteJob(ctx, running.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		assertQueueTestStatus(t, store, queued.ID, JobStatusCancelled)
+	})
+
+	t.Run("fails jobs whose launch was interrupted", func(t *testing.T) {
+		store, runtime, schedule := setupJobTest(t)
+		limits := ConcurrencyLimits{MaxConcurrentJobs: 1}
+
+		// A job dispatched by a process that crashed before launching its Machine
+		stalled, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.QueueJob(ctx, stalled.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET dispatched_at = ? WHERE id = ?", time.Now().Add(-2*launchDeadline), stalled.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// It holds the only slot until it's reconciled
+		queued, err := ProcessJob(ctx, log, store, runtime, schedule.ID, limits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if queued.Status != JobStatusPending || queued.DispatchedAt.Valid {
+			t.Fatalf("expected job to be queued, got %s", queued.Status)
+		}
+
+		if err := FailStalledLaunches(ctx, log, store, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		job, err := store.FindJob(ctx, fmt.Sprint(stalled.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != JobStatusFailed || job.FailureReason.String != FailureReasonLaunchFailed {
+			t.Fatalf("expected the stalled job to fail with %s, got %s %s", FailureReasonLaunchFailed, job.Status, job.FailureReason.String)
+		}
+
+		if err := DispatchJobs(ctx, log, store, runtime, limits); err != nil {
+			t.Fatal(err)
+		}
+
+		assertQueueTestStatus(t, store, queued.ID, JobStatusRunning)
+
+		// Jobs dispatched within the deadline may still be launching
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET machine_id = NULL, status = ?, dispatched_at = ? WHERE id = ?", JobStatusPending, time.Now(), queued.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := FailStalledLaunches(ctx, log, store, time.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		assertQueueTestStatus(t, store, queued.ID, JobStatusPending)
+	})
+}
+
+// cancellingRuntimeProvider cancels the context of the request that launches a Machine.
+type cancellingRuntimeProvider struct {
+	runtime *SimulatedRuntime
+	cancel  context.CancelFunc
+}
+
+func (p cancellingRuntimeProvider) Runtime(ctx context.Context, appName string) (MachineRuntime, error) {
+	p.cancel()
+	return p.runtime, nil
+}
+
+// queueTestSchedule names the schedule and sets its app and priority.
+func queueTestSchedule(name, appName string, priority int) func(*Schedule) {
+	return func(s *Schedule) {
+		s.Name = name
+		s.AppName = appName
+		s.Priority = priority
+	}
+}
+
+func assertQueueTestStatus(t *testing.T, store *Store, jobID int, status string) {
+	t.Helper()
+
+	job, err := store.FindJob(context.TODO(), fmt.Sprint(jobID))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != status {
+		t.Fatalf("expected job %d to be %s, got %s", jobID, status, job.Status)
+	}
+}
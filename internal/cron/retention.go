@@ -0,0 +1,170 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const pruneFrequency = time.Hour
+
+const (
+	// RetentionKeepAll opts a schedule out of the global keep_last.
+	RetentionKeepAll = -1
+	// RetentionMaxAgeOff opts a schedule out of the global max_age.
+	RetentionMaxAgeOff = "off"
+)
+
+// Retention limits how much job history is kept for a schedule.
+// Finished jobs are pruned once they fall outside the newest KeepLast jobs, or once they are older than MaxAge.
+// Unset fields fall back to the global policy, RetentionKeepAll and RetentionMaxAgeOff disable a limit regardless of it.
+type Retention struct {
+	KeepLast int `json:"keep_last,omitempty"`
+	// MaxAge is a duration such as 720h, days may be specified as e.g. 30d.
+	MaxAge string `json:"max_age,omitempty"`
+}
+
+// PruneResult describes the jobs pruned, or that would be pruned, for a schedule.
+type PruneResult struct {
+	ScheduleID   int    `json:"schedule_id"`
+	ScheduleName string `json:"schedule_name"`
+	Jobs         int    `json:"jobs"`
+}
+
+// RetentionFromEnv returns the global retention policy set with JOB_RETENTION_KEEP_LAST and JOB_RETENTION_MAX_AGE.
+// History is kept forever unless one of them is set.
+func RetentionFromEnv() (Retention, error) {
+	var retention Retention
+
+	if keepLast := getEnvOrDefault("JOB_RETENTION_KEEP_LAST", ""); keepLast != "" {
+		n, err := strconv.Atoi(keepLast)
+		if err != nil {
+			return retention, fmt.Errorf("invalid JOB_RETENTION_KEEP_LAST: %w", err)
+		}
+		retention.KeepLast = n
+	}
+
+	retention.MaxAge = getEnvOrDefault("JOB_RETENTION_MAX_AGE", "")
+
+	return retention, retention.Validate()
+}
+
+func (r Retention) Validate() error {
+	if r.KeepLast < RetentionKeepAll {
+		return fmt.Errorf("keep_last must be %d or more", RetentionKeepAll)
+	}
+
+	if _, err := r.maxAge(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// merge returns the retention with any unset fields taken from the defaults.
+func (r Retention) merge(defaults Retention) Retention {
+	if r.KeepLast == 0 {
+		r.KeepLast = defaults.KeepLast
+	}
+
+	if r.MaxAge == "" {
+		r.MaxAge = defaults.MaxAge
+	}
+
+	return r
+}
+
+func (r Retention) maxAge() (time.Duration, error) {
+	if r.MaxAge == "" || r.MaxAge == RetentionMaxAgeOff {
+		return 0, nil
+	}
+
+	d, err := ParseDuration(r.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_age %q: %w", r.MaxAge, err)
+	}
+
+	return d, nil
+}
+
+// ParseDuration parses a duration such as 90m or 720h, also accepting a number of days such as 30d.
+func ParseDuration(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(s)
+}
+
+// PruneJobs enforces the retention policy of every schedule, falling back to the global policy.
+// Pruned jobs are counted in the daily summaries before they are deleted. Nothing is deleted when dryRun is set.
+func PruneJobs(ctx context.Context, store *Store, log *logrus.Logger, global Retention, dryRun bool) ([]PruneResult, error) {
+	schedules, err := store.ListSchedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	var results []PruneResult
+	for _, schedule := range schedules {
+		retention := schedule.Retention.merge(global)
+
+		maxAge, err := retention.maxAge()
+		if err != nil {
+			log.WithError(err).Warnf("Skipping schedule %s with an invalid retention policy", schedule.Name)
+			continue
+		}
+
+		if retention.KeepLast <= 0 && maxAge == 0 {
+			continue
+		}
+
+		jobs, err := store.ListFinishedJobs(ctx, schedule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list jobs: %w", err)
+		}
+
+		cutoff := time.Now().Add(-maxAge)
+
+		var prunable []Job
+		for i, job := range jobs {
+			if retention.KeepLast > 0 && i >= retention.KeepLast {
+				prunable = append(prunable, job)
+				continue
+			}
+
+			if maxAge > 0 && job.CreatedAt.Before(cutoff) {
+				prunable = append(prunable, job)
+			}
+		}
+
+		if len(prunable) == 0 {
+			continue
+		}
+
+		results = append(results, PruneResult{
+			ScheduleID:   schedule.ID,
+			ScheduleName: schedule.Name,
+			Jobs:         len(prunable),
+		})
+
+		if dryRun {
+			continue
+		}
+
+		if err := store.PruneJobs(ctx, prunable); err != nil {
+			return nil, fmt.Errorf("failed to prune jobs for schedule %s: %w", schedule.Name, err)
+		}
+
+		log.Infof("Pruned %d job(s) for schedule %s", len(prunable), schedule.Name)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,186 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestPruneJobs(t *testing.T) {
+	ctx := context.TODO()
+	log := logrus.New()
+
+	store, _, schedule := setupJobTest(t)
+
+	for i := 0; i < 5; i++ {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	running, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateJobStatus(ctx, running.ID, JobStatusRunning); err != nil {
+		t.Fatal(err)
+	}
+
+	global := Retention{KeepLast: 2}
+
+	t.Run("reports prunable jobs on a dry run", func(t *testing.T) {
+		results, err := PruneJobs(ctx, store, log, global, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) != 1 || results[0].Jobs != 3 {
+			t.Fatalf("expected 3 jobs to be prunable, got %+v", results)
+		}
+
+		jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(jobs) != 6 {
+			t.Fatalf("expected no jobs to be deleted, got %d jobs", len(jobs))
+		}
+	})
+
+	t.Run("summarizes and deletes jobs beyond keep_last", func(t *testing.T) {
+		if _, err := PruneJobs(ctx, store, log, global, false); err != nil {
+			t.Fatal(err)
+		}
+
+		jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The two newest finished jobs are kept, along with the running job.
+		if len(jobs) != 3 {
+			t.Fatalf("expected 3 jobs to remain, got %d", len(jobs))
+		}
+
+		summaries, err := store.ListJobDailySummaries(ctx, schedule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(summaries) != 1 || summaries[0].Status != JobStatusCompleted || summaries[0].Count != 3 {
+			t.Fatalf("expected a summary of 3 completed jobs, got %+v", summaries)
+		}
+	})
+
+	t.Run("schedule retention overrides the global policy", func(t *testing.T) {
+		schedule.Retention = Retention{MaxAge: "1d"}
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ? WHERE status = ?", time.Now().Add(-48*time.Hour), JobStatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := PruneJobs(ctx, store, log, Retention{KeepLast: 100}, false); err != nil {
+			t.Fatal(err)
+		}
+
+		jobs, err := store.ListJobs(ctx, fmt.Sprint(schedule.ID), 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(jobs) != 1 || jobs[0].ID != running.ID {
+			t.Fatalf("expected only the running job to remain, got %d jobs", len(jobs))
+		}
+	})
+
+	t.Run("schedules opt out of the global policy", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ? WHERE status = ?", time.Now().Add(-48*time.Hour), JobStatusCompleted); err != nil {
+			t.Fatal(err)
+		}
+
+		schedule.Retention = Retention{KeepLast: RetentionKeepAll, MaxAge: RetentionMaxAgeOff}
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		results, err := PruneJobs(ctx, store, log, Retention{KeepLast: 1, MaxAge: "1d"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(results) != 0 {
+			t.Fatalf("expected no jobs to be pruned, got %+v", results)
+		}
+	})
+
+	t.Run("summarizes durations from the machine starting", func(t *testing.T) {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.FailJob(ctx, job.ID, 1, "", ""); err != nil {
+			t.Fatal(err)
+		}
+
+		// Queued for an hour, then ran for 30 seconds
+		createdAt := time.Now().Add(-72 * time.Hour).UTC()
+		startedAt := createdAt.Add(time.Hour)
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ?, finished_at = ? WHERE id = ?", createdAt, startedAt.Add(30*time.Second), job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		start := JobEvent{JobID: job.ID, Type: JobEventStart, Source: "test", Timestamp: startedAt}
+		if err := store.RecordJobEvents(ctx, []JobEvent{start}); err != nil {
+			t.Fatal(err)
+		}
+
+		job, err = store.FindJob(ctx, fmt.Sprint(job.ID))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.PruneJobs(ctx, []Job{*job}); err != nil {
+			t.Fatal(err)
+		}
+
+		summaries, err := store.ListJobDailySummaries(ctx, schedule.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, summary := range summaries {
+			if summary.Status != JobStatusFailed {
+				continue
+			}
+
+			if summary.Count != 1 || summary.TotalDuration < 29.99 || summary.TotalDuration > 30.01 {
+				t.Fatalf("expected a summary of 1 failed job lasting 30s, got %+v", summary)
+			}
+			return
+		}
+
+		t.Fatalf("expected a summary of the failed job, got %+v", summaries)
+	})
+}
@@ -0,0 +1,165 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+)
+
+const (
+	// Execution backends, selected with the EXECUTION_BACKEND environment variable.
+	BackendFly     = "fly"
+	BackendProcess = "process"
+	BackendDocker  = "docker"
+
+	DefaultLocalStateDir = "/data/machines"
+)
+
+var (
+	// ErrMachineNotFound is returned when a Machine no longer exists, or is no longer queryable.
+	ErrMachineNotFound = errors.New("machine not found")
+	// ErrRateLimited is returned when the runtime is rejecting requests due to rate limiting.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrLaunchRejected is returned when a launch is rejected for a reason that doesn't depend on the region,
+	// such as an invalid Machine config, so launching in another region would fail the same way.
+	ErrLaunchRejected = errors.New("launch rejected")
+)
+
+// MachineRuntime manages the Machines jobs are executed on.
+type MachineRuntime interface {
+	MachineProvision(ctx context.Context, input fly.LaunchMachineInput) (*fly.Machine, error)
+	MachineGet(ctx context.Context, machineID string) (*fly.Machine, error)
+	MachineList(ctx context.Context, state string) ([]*fly.Machine, error)
+	MachineStop(ctx context.Context, machine *fly.Machine, signal string, timeout time.Duration) error
+	MachineDestroy(ctx context.Context, machine *fly.Machine) error
+	// MachineStart starts a stopped Machine.
+	MachineStart(ctx context.Context, machineID string) error
+	// MachineUpdate replaces the config of a stopped Machine without starting it.
+	MachineUpdate(ctx context.Context, machine *fly.Machine, config *fly.MachineConfig) (*fly.Machine, error)
+	// WaitForExit blocks until the Machine has stopped or been destroyed, giving up once the timeout elapses.
+	WaitForExit(ctx context.Context, machineID string, timeout time.Duration) error
+}
+
+// OutputReader is implemented by runtimes that capture the output of the command.
+type OutputReader interface {
+	MachineOutput(ctx context.Context, machineID string) (stdout string, stderr string, err error)
+}
+
+// RuntimeProvider returns the MachineRuntime responsible for an app's Machines.
+type RuntimeProvider interface {
+	Runtime(ctx context.Context, appName string) (MachineRuntime, error)
+}
+
+// FlapsProvider provides Fly backed runtimes, caching a single client per app.
+type FlapsProvider struct {
+	mu      sync.Mutex
+	clients map[string]*FlapsClient
+}
+
+func NewFlapsProvider() *FlapsProvider {
+	return &FlapsProvider{
+		clients: make(map[string]*FlapsClient),
+	}
+}
+
+func (p *FlapsProvider) Runtime(ctx context.Context, appName string) (MachineRuntime, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if client, ok := p.clients[appName]; ok {
+		return client, nil
+	}
+
+	client, err := NewFlapsClient(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	p.clients[appName] = client
+
+	return client, nil
+}
+
+// NewRuntimeProviderFromEnv returns the provider for the execution backend configured with EXECUTION_BACKEND.
+// Jobs run on Fly Machines unless a local backend is selected.
+func NewRuntimeProviderFromEnv() (RuntimeProvider, error) {
+	backend := getEnvOrDefault("EXECUTION_BACKEND", BackendFly)
+
+	switch backend {
+	case BackendFly:
+		return NewFlapsProvider(), nil
+	case BackendProcess, BackendDocker:
+		return NewLocalRuntime(backend, getEnvOrDefault("LOCAL_STATE_DIR", DefaultLocalStateDir))
+	default:
+		return nil, fmt.Errorf("unsupported execution backend %q", backend)
+	}
+}
+
+// waitForMachineExit polls the machine until it has stopped or been destroyed, giving up once the timeout elapses.
+func waitForMachineExit(ctx context.Context, runtime MachineRuntime, machineID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(exitPollFrequency)
+	defer ticker.Stop()
+
+	for {
+		machine, err := runtime.MachineGet(ctx, machineID)
+		if err == nil {
+			switch machine.State {
+			case fly.MachineStateStopped, fly.MachineStateDestroying, fly.MachineStateDestroyed:
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("machine %s did not exit within %s", machineID, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// addMachineEvent prepends the event, Fly reports the most recent events first.
+func addMachineEvent(machine *fly.Machine, eventType, status, source string, at time.Time, request *fly.MachineRequest) {
+	event := &fly.MachineEvent{
+		Type:      eventType,
+		Status:    status,
+		Source:    source,
+		Request:   request,
+		Timestamp: at.UnixMilli(),
+	}
+	machine.Events = append([]*fly.MachineEvent{event}, machine.Events...)
+}
+
+func exitRequest(exitCode int) *fly.MachineRequest {
+	return &fly.MachineRequest{
+		ExitEvent: &fly.MachineExitEvent{ExitCode: exitCode},
+	}
+}
+
+// parseSignal converts a signal name such as SIGTERM to its signal, defaulting to SIGTERM.
+func parseSignal(name string) syscall.Signal {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "HUP":
+		return syscall.SIGHUP
+	case "INT":
+		return syscall.SIGINT
+	case "QUIT":
+		return syscall.SIGQUIT
+	case "KILL":
+		return syscall.SIGKILL
+	case "USR1":
+		return syscall.SIGUSR1
+	case "USR2":
+		return syscall.SIGUSR2
+	default:
+		return syscall.SIGTERM
+	}
+}
@@ -0,0 +1,682 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fly-apps/cron-manager/internal/supervisor"
+	fly "github.com/superfly/fly-go"
+)
+
+const (
+	localRegion      = "local"
+	localEventSource = "local"
+
+	// maxLocalOutput caps how much of the command output is reported back to the job.
+	maxLocalOutput = 64 * 1024
+)
+
+// LocalRuntime runs jobs on the host instead of on Fly, either as a subprocess or inside a Docker container.
+//
+// The api and monitor run as separate processes, so Machine state is persisted to the state directory
+// where each process can read it.
+type LocalRuntime struct {
+	backend  string
+	stateDir string
+
+	mu sync.Mutex
+	wg sync.WaitGroup
+}
+
+func NewLocalRuntime(backend, stateDir string) (*LocalRuntime, error) {
+	if backend != BackendProcess && backend != BackendDocker {
+		return nil, fmt.Errorf("unsupported local backend %q", backend)
+	}
+
+	if err := os.MkdirAll(stateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	return &LocalRuntime{
+		backend:  backend,
+		stateDir: stateDir,
+	}, nil
+}
+
+// Runtime returns the same LocalRuntime for every app.
+func (r *LocalRuntime) Runtime(ctx context.Context, appName string) (MachineRuntime, error) {
+	return r, nil
+}
+
+func (r *LocalRuntime) MachineProvision(ctx context.Context, input fly.LaunchMachineInput) (*fly.Machine, error) {
+	var config fly.MachineConfig
+	if input.Config != nil {
+		config = *input.Config
+	}
+
+	if len(config.Init.Cmd) == 0 {
+		return nil, fmt.Errorf("failed to launch machine: no command specified")
+	}
+
+	id, err := newLocalMachineID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate machine id: %w", err)
+	}
+
+	if err := os.MkdirAll(r.machineDir(id), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create machine directory: %w", err)
+	}
+
+	machine := &fly.Machine{
+		ID:         id,
+		State:      fly.MachineStateCreated,
+		Region:     localRegion,
+		InstanceID: id,
+		Config:     &config,
+	}
+	addMachineEvent(machine, JobEventLaunch, fly.MachineStateCreated, localEventSource, time.Now(), nil)
+
+	switch r.backend {
+	case BackendProcess:
+		err = r.startProcess(machine)
+	case BackendDocker:
+		err = r.startContainer(ctx, machine)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to launch machine: %w", err)
+	}
+
+	return machine, nil
+}
+
+// Wait blocks until every process started by this runtime has exited.
+// Processes are killed along with their parent, so short-lived callers must wait for them to finish.
+func (r *LocalRuntime) Wait() {
+	r.wg.Wait()
+}
+
+func (r *LocalRuntime) MachineGet(ctx context.Context, machineID string) (*fly.Machine, error) {
+	machine, err := r.get(ctx, machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.backend != BackendProcess || machine.State != fly.MachineStateStarted {
+		return machine, nil
+	}
+
+	pid, err := r.pid(machineID)
+	if err != nil || processAlive(pid) {
+		return machine, nil
+	}
+
+	// The process is gone, give its owner a moment to record the exit before assuming it was lost.
+	time.Sleep(exitPollFrequency)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, err = r.load(machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if machine.State == fly.MachineStateStarted {
+		machine.State = fly.MachineStateDestroyed
+		addMachineEvent(machine, JobEventDestroy, fly.MachineStateDestroyed, localEventSource, time.Now(), nil)
+
+		if err := r.save(machine); err != nil {
+			return nil, err
+		}
+	}
+
+	return machine, nil
+}
+
+func (r *LocalRuntime) get(ctx context.Context, machineID string) (*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, err := r.load(machineID)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.backend == BackendDocker && machine.State == fly.MachineStateStarted {
+		if err := r.refreshContainer(ctx, machine); err != nil {
+			return nil, err
+		}
+	}
+
+	return machine, nil
+}
+
+func (r *LocalRuntime) MachineList(ctx context.Context, state string) ([]*fly.Machine, error) {
+	entries, err := os.ReadDir(r.stateDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state directory: %w", err)
+	}
+
+	var machines []*fly.Machine
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		machine, err := r.MachineGet(ctx, entry.Name())
+		if err != nil {
+			continue
+		}
+
+		if machine.State == fly.MachineStateDestroyed {
+			continue
+		}
+
+		if state != "" && machine.State != state {
+			continue
+		}
+
+		machines = append(machines, machine)
+	}
+
+	return machines, nil
+}
+
+func (r *LocalRuntime) MachineStop(ctx context.Context, machine *fly.Machine, signal string, timeout time.Duration) error {
+	switch r.backend {
+	case BackendProcess:
+		pid, err := r.pid(machine.ID)
+		if err != nil {
+			return nil
+		}
+
+		if err := supervisor.SignalGroup(pid, parseSignal(signal)); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			return fmt.Errorf("failed to signal process: %w", err)
+		}
+	case BackendDocker:
+		if _, err := docker(ctx, "kill", "--signal", signal, containerName(machine.ID)); err != nil {
+			return fmt.Errorf("failed to signal container: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *LocalRuntime) MachineDestroy(ctx context.Context, machine *fly.Machine) error {
+	switch r.backend {
+	case BackendProcess:
+		if pid, err := r.pid(machine.ID); err == nil {
+			_ = supervisor.SignalGroup(pid, syscall.SIGKILL)
+		}
+	case BackendDocker:
+		if _, err := docker(ctx, "rm", "--force", containerName(machine.ID)); err != nil && !isNoSuchContainer(err) {
+			return fmt.Errorf("failed to remove container: %w", err)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.load(machine.ID)
+	if err != nil {
+		if errors.Is(err, ErrMachineNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	if m.State == fly.MachineStateDestroyed {
+		return nil
+	}
+
+	m.State = fly.MachineStateDestroyed
+	addMachineEvent(m, JobEventDestroy, fly.MachineStateDestroyed, localEventSource, time.Now(), nil)
+
+	return r.save(m)
+}
+
+func (r *LocalRuntime) MachineStart(ctx context.Context, machineID string) error {
+	r.mu.Lock()
+	machine, err := r.load(machineID)
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if machine.State != fly.MachineStateStopped {
+		return fmt.Errorf("machine %s is %s", machineID, machine.State)
+	}
+
+	switch r.backend {
+	case BackendProcess:
+		return r.startProcess(machine)
+	case BackendDocker:
+		if _, err := docker(ctx, "start", containerName(machine.ID)); err != nil {
+			return err
+		}
+
+		machine.State = fly.MachineStateStarted
+		addMachineEvent(machine, JobEventStart, fly.MachineStateStarted, localEventSource, time.Now(), nil)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		return r.save(machine)
+	}
+
+	return nil
+}
+
+func (r *LocalRuntime) MachineUpdate(ctx context.Context, machine *fly.Machine, config *fly.MachineConfig) (*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, err := r.load(machine.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := *config
+	m.Config = &c
+
+	// Containers can not be reconfigured, so they are recreated with the new config.
+	if r.backend == BackendDocker {
+		if _, err := docker(ctx, "rm", "--force", containerName(m.ID)); err != nil && !isNoSuchContainer(err) {
+			return nil, fmt.Errorf("failed to remove container: %w", err)
+		}
+
+		if _, err := docker(ctx, append([]string{"create"}, containerArgs(m)...)...); err != nil {
+			return nil, fmt.Errorf("failed to create container: %w", err)
+		}
+
+		if err := setContainerImage(ctx, m); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.save(m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (r *LocalRuntime) WaitForExit(ctx context.Context, machineID string, timeout time.Duration) error {
+	return waitForMachineExit(ctx, r, machineID, timeout)
+}
+
+// MachineOutput returns the captured stdout and stderr of the command.
+func (r *LocalRuntime) MachineOutput(ctx context.Context, machineID string) (string, string, error) {
+	stdout, err := readTail(filepath.Join(r.machineDir(machineID), "stdout"))
+	if err != nil {
+		return "", "", err
+	}
+
+	stderr, err := readTail(filepath.Join(r.machineDir(machineID), "stderr"))
+	if err != nil {
+		return "", "", err
+	}
+
+	return stdout, stderr, nil
+}
+
+// processEnvAllowlist are the variables of the cron manager's environment passed through to job processes.
+// Everything else, such as FLY_API_TOKEN and API_TOKEN, is withheld.
+var processEnvAllowlist = []string{"PATH", "HOME"}
+
+// processEnv returns the environment of a job process, the allowlisted variables along with the config's env.
+func processEnv(config *fly.MachineConfig) []string {
+	// A nil environment would inherit the cron manager's
+	env := []string{}
+	for _, key := range processEnvAllowlist {
+		if value, ok := os.LookupEnv(key); ok {
+			env = append(env, fmt.Sprintf("%s=%s", key, value))
+		}
+	}
+
+	for k, v := range config.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return env
+}
+
+func (r *LocalRuntime) startProcess(machine *fly.Machine) error {
+	dir := r.machineDir(machine.ID)
+
+	cmd, err := supervisor.NewCommand(machine.Config.Init.Cmd, processEnv(machine.Config))
+	if err != nil {
+		return err
+	}
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		return err
+	}
+
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		_ = stdout.Close()
+		return err
+	}
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	if err := cmd.Start(); err != nil {
+		_ = stdout.Close()
+		_ = stderr.Close()
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "pid"), []byte(strconv.Itoa(cmd.Process.Pid)), 0o644); err != nil {
+		return err
+	}
+
+	machine.State = fly.MachineStateStarted
+	addMachineEvent(machine, JobEventStart, fly.MachineStateStarted, localEventSource, time.Now(), nil)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.save(machine); err != nil {
+		return err
+	}
+
+	// The process is owned by this process, record its exit so other processes can evaluate it.
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		_ = cmd.Wait()
+		_ = stdout.Close()
+		_ = stderr.Close()
+
+		r.recordExit(machine.ID, processExitCode(cmd.ProcessState), time.Now())
+	}()
+
+	return nil
+}
+
+func (r *LocalRuntime) startContainer(ctx context.Context, machine *fly.Machine) error {
+	if _, err := docker(ctx, append([]string{"run", "--detach"}, containerArgs(machine)...)...); err != nil {
+		return err
+	}
+
+	if err := setContainerImage(ctx, machine); err != nil {
+		return err
+	}
+
+	machine.State = fly.MachineStateStarted
+	addMachineEvent(machine, JobEventStart, fly.MachineStateStarted, localEventSource, time.Now(), nil)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.save(machine)
+}
+
+// containerArgs returns the arguments used to create the Machine's container.
+func containerArgs(machine *fly.Machine) []string {
+	args := []string{
+		"--name", containerName(machine.ID),
+		"--label", fmt.Sprintf("%s=true", managedByMetadataKey),
+	}
+
+	for k, v := range machine.Config.Env {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	if machine.Config.Guest != nil && machine.Config.Guest.MemoryMB > 0 {
+		args = append(args, "--memory", fmt.Sprintf("%dm", machine.Config.Guest.MemoryMB))
+	}
+
+	args = append(args, machine.Config.Image)
+	args = append(args, machine.Config.Init.Cmd...)
+
+	return args
+}
+
+// setContainerImage records the image the container was created from on the Machine.
+func setContainerImage(ctx context.Context, machine *fly.Machine) error {
+	out, err := docker(ctx, "inspect", "--format", "{{.Image}}", containerName(machine.ID))
+	if err != nil {
+		return err
+	}
+
+	machine.ImageRef = fly.MachineImageRef{
+		Repository: machine.Config.Image,
+		Digest:     strings.TrimSpace(string(out)),
+	}
+
+	return nil
+}
+
+// ResolveImage pulls the image and returns its repository digest.
+func (r *LocalRuntime) ResolveImage(ctx context.Context, appName, image string) (string, error) {
+	if r.backend != BackendDocker {
+		return "", fmt.Errorf("images can not be resolved by the %s backend", r.backend)
+	}
+
+	if _, err := docker(ctx, "pull", "--quiet", image); err != nil {
+		return "", err
+	}
+
+	out, err := docker(ctx, "image", "inspect", "--format", "{{index .RepoDigests 0}}", image)
+	if err != nil {
+		return "", err
+	}
+
+	_, digest, ok := strings.Cut(strings.TrimSpace(string(out)), "@")
+	if !ok {
+		return "", fmt.Errorf("image %s has no repository digest", image)
+	}
+
+	return digest, nil
+}
+
+type containerState struct {
+	Status     string    `json:"Status"`
+	ExitCode   int       `json:"ExitCode"`
+	FinishedAt time.Time `json:"FinishedAt"`
+}
+
+// refreshContainer records the exit of a container that is no longer running, capturing its logs.
+// Containers are removed once they've exited when the Machine is configured to auto destroy.
+func (r *LocalRuntime) refreshContainer(ctx context.Context, machine *fly.Machine) error {
+	out, err := docker(ctx, "inspect", "--format", "{{json .State}}", containerName(machine.ID))
+	if err != nil {
+		if isNoSuchContainer(err) {
+			return fmt.Errorf("%w: %s", ErrMachineNotFound, machine.ID)
+		}
+		return err
+	}
+
+	var state containerState
+	if err := json.Unmarshal(out, &state); err != nil {
+		return fmt.Errorf("failed to parse container state: %w", err)
+	}
+
+	if state.Status != "exited" && state.Status != "dead" {
+		return nil
+	}
+
+	dir := r.machineDir(machine.ID)
+
+	// Reused containers accumulate logs across runs, only capture those of the latest run.
+	logsArgs := []string{"logs"}
+	if start := findEvent(machine, JobEventStart); start != nil {
+		logsArgs = append(logsArgs, "--since", start.Time().UTC().Format(time.RFC3339Nano))
+	}
+
+	logs := exec.CommandContext(ctx, "docker", append(logsArgs, containerName(machine.ID))...)
+	var stdout, stderr bytes.Buffer
+	logs.Stdout = &stdout
+	logs.Stderr = &stderr
+	if err := logs.Run(); err != nil {
+		return fmt.Errorf("failed to read container logs: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "stdout"), stdout.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "stderr"), stderr.Bytes(), 0o644); err != nil {
+		return err
+	}
+
+	markExited(machine, state.ExitCode, state.FinishedAt)
+
+	if machine.State == fly.MachineStateDestroyed {
+		if _, err := docker(ctx, "rm", "--force", containerName(machine.ID)); err != nil && !isNoSuchContainer(err) {
+			return fmt.Errorf("failed to remove container: %w", err)
+		}
+	}
+
+	return r.save(machine)
+}
+
+func (r *LocalRuntime) recordExit(machineID string, exitCode int, at time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, err := r.load(machineID)
+	if err != nil {
+		return
+	}
+
+	markExited(machine, exitCode, at)
+
+	_ = r.save(machine)
+}
+
+// markExited records the exit event, destroying the Machine if it is configured to auto destroy.
+func markExited(machine *fly.Machine, exitCode int, at time.Time) {
+	destroyed := machine.State == fly.MachineStateDestroyed
+
+	addMachineEvent(machine, JobEventExit, fly.MachineStateStopped, localEventSource, at, exitRequest(exitCode))
+	machine.State = fly.MachineStateStopped
+
+	if destroyed || machine.Config.AutoDestroy {
+		machine.State = fly.MachineStateDestroyed
+		if !destroyed {
+			addMachineEvent(machine, JobEventDestroy, fly.MachineStateDestroyed, localEventSource, at, nil)
+		}
+	}
+}
+
+func (r *LocalRuntime) machineDir(machineID string) string {
+	return filepath.Join(r.stateDir, machineID)
+}
+
+func (r *LocalRuntime) pid(machineID string) (int, error) {
+	b, err := os.ReadFile(filepath.Join(r.machineDir(machineID), "pid"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func (r *LocalRuntime) load(machineID string) (*fly.Machine, error) {
+	b, err := os.ReadFile(filepath.Join(r.machineDir(machineID), "machine.json"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrMachineNotFound, machineID)
+		}
+		return nil, err
+	}
+
+	var machine fly.Machine
+	if err := json.Unmarshal(b, &machine); err != nil {
+		return nil, fmt.Errorf("failed to parse machine state: %w", err)
+	}
+
+	return &machine, nil
+}
+
+// save writes the Machine state atomically, so readers in other processes never observe a partial write.
+func (r *LocalRuntime) save(machine *fly.Machine) error {
+	b, err := json.Marshal(machine)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(r.machineDir(machine.ID), "machine.json")
+	if err := os.WriteFile(path+".tmp", b, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(path+".tmp", path)
+}
+
+func docker(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "docker", args...)
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
+
+func isNoSuchContainer(err error) bool {
+	return strings.Contains(err.Error(), "No such")
+}
+
+func containerName(machineID string) string {
+	return "cm-" + machineID
+}
+
+func newLocalMachineID() (string, error) {
+	b := make([]byte, 7)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processExitCode returns the exit code of the process, following the shell convention of 128+n for signals.
+func processExitCode(state *os.ProcessState) int {
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal())
+	}
+	return state.ExitCode()
+}
+
+func readTail(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	if len(b) > maxLocalOutput {
+		b = b[len(b)-maxLocalOutput:]
+	}
+
+	return string(b), nil
+}
@@ -0,0 +1,95 @@
+package cron
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+)
+
+func TestLocalProcessRuntime(t *testing.T) {
+	ctx := context.TODO()
+
+	runtime, err := NewLocalRuntime(BackendProcess, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("reports exit codes and output", func(t *testing.T) {
+		// Secrets of the cron manager must not leak into jobs
+		t.Setenv("API_TOKEN", "secret")
+
+		machine, err := runtime.MachineProvision(ctx, fly.LaunchMachineInput{
+			Config: &fly.MachineConfig{
+				AutoDestroy: true,
+				Env:         map[string]string{"GREETING": "hello"},
+				Init:        fly.MachineInit{Cmd: []string{"sh", "-c", "echo $GREETING $API_TOKEN; echo oops >&2; exit 3"}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := runtime.WaitForExit(ctx, machine.ID, 10*time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		machine, err = runtime.MachineGet(ctx, machine.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if machine.State != fly.MachineStateDestroyed {
+			t.Fatalf("expected machine to be destroyed, got %s", machine.State)
+		}
+
+		event := findEvent(machine, JobEventExit)
+		if event == nil || event.Request.ExitEvent.ExitCode != 3 {
+			t.Fatal("expected an exit event with exit code 3")
+		}
+
+		stdout, stderr, err := runtime.MachineOutput(ctx, machine.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if strings.TrimSpace(stdout) != "hello" || strings.TrimSpace(stderr) != "oops" {
+			t.Fatalf("unexpected output %q %q", stdout, stderr)
+		}
+	})
+
+	t.Run("stops processes with a signal", func(t *testing.T) {
+		machine, err := runtime.MachineProvision(ctx, fly.LaunchMachineInput{
+			Config: &fly.MachineConfig{
+				Init: fly.MachineInit{Cmd: []string{"sleep", "30"}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := runtime.MachineStop(ctx, machine, "SIGTERM", time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := runtime.WaitForExit(ctx, machine.ID, 10*time.Second); err != nil {
+			t.Fatal(err)
+		}
+
+		machine, err = runtime.MachineGet(ctx, machine.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if machine.State != fly.MachineStateStopped {
+			t.Fatalf("expected machine to be stopped, got %s", machine.State)
+		}
+
+		event := findEvent(machine, JobEventExit)
+		if event == nil || event.Request.ExitEvent.ExitCode != 143 {
+			t.Fatal("expected an exit event with exit code 143")
+		}
+	})
+}
@@ -0,0 +1,341 @@
+package cron
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	fly "github.com/superfly/fly-go"
+)
+
+const simulatorEventSource = "simulator"
+
+// SimulatedRuntime is an in-memory MachineRuntime that lets tests script the lifecycle of Machines.
+// It satisfies RuntimeProvider as well, returning itself for every app.
+type SimulatedRuntime struct {
+	mu       sync.Mutex
+	machines map[string]*fly.Machine
+	nextID   int
+
+	launchErrs  []error
+	listErr     error
+	destroyErr  error
+	ignoreStop  map[string]bool
+	stopSignals map[string]string
+	onProvision func(machine *fly.Machine)
+}
+
+func NewSimulatedRuntime() *SimulatedRuntime {
+	return &SimulatedRuntime{
+		machines:    make(map[string]*fly.Machine),
+		ignoreStop:  make(map[string]bool),
+		stopSignals: make(map[string]string),
+	}
+}
+
+func (r *SimulatedRuntime) Runtime(ctx context.Context, appName string) (MachineRuntime, error) {
+	return r, nil
+}
+
+// FailNextLaunch causes the next MachineProvision call to return the specified error.
+func (r *SimulatedRuntime) FailNextLaunch(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.launchErrs = append(r.launchErrs, err)
+}
+
+// OnProvision calls the function with each Machine once it has been provisioned, before MachineProvision returns.
+func (r *SimulatedRuntime) OnProvision(fn func(machine *fly.Machine)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.onProvision = fn
+}
+
+// FailList causes MachineList to return the specified error until it is cleared with nil.
+func (r *SimulatedRuntime) FailList(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.listErr = err
+}
+
+// FailDestroy causes MachineDestroy to return the specified error until it is cleared with nil.
+func (r *SimulatedRuntime) FailDestroy(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.destroyErr = err
+}
+
+// IgnoreStop causes the Machine to keep running when it is asked to stop.
+func (r *SimulatedRuntime) IgnoreStop(machineID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ignoreStop[machineID] = true
+}
+
+// StartMachine transitions the Machine to the started state as of the specified time.
+func (r *SimulatedRuntime) StartMachine(machineID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, ok := r.machines[machineID]
+	if !ok {
+		return ErrMachineNotFound
+	}
+
+	machine.State = fly.MachineStateStarted
+	addMachineEvent(machine, JobEventStart, fly.MachineStateStarted, simulatorEventSource, at, nil)
+
+	return nil
+}
+
+// ExitMachine reports the command exiting with the exit code at the specified time.
+// Machines configured to auto destroy are destroyed as they would be on Fly.
+func (r *SimulatedRuntime) ExitMachine(machineID string, exitCode int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, ok := r.machines[machineID]
+	if !ok {
+		return ErrMachineNotFound
+	}
+
+	r.exit(machine, exitRequest(exitCode), at)
+
+	return nil
+}
+
+// ExitMachineWithoutCode reports the command exiting at the specified time, with an exit event that omits the exit code.
+func (r *SimulatedRuntime) ExitMachineWithoutCode(machineID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, ok := r.machines[machineID]
+	if !ok {
+		return ErrMachineNotFound
+	}
+
+	r.exit(machine, nil, at)
+
+	return nil
+}
+
+// RemoveMachine forgets the Machine entirely, as if it were no longer queryable.
+func (r *SimulatedRuntime) RemoveMachine(machineID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.machines, machineID)
+}
+
+// StopSignal returns the signal the Machine was asked to stop with, if any.
+func (r *SimulatedRuntime) StopSignal(machineID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stopSignals[machineID]
+}
+
+func (r *SimulatedRuntime) MachineProvision(ctx context.Context, input fly.LaunchMachineInput) (*fly.Machine, error) {
+	machine, err := r.provision(input)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	onProvision := r.onProvision
+	r.mu.Unlock()
+
+	if onProvision != nil {
+		onProvision(machine)
+	}
+
+	return machine, nil
+}
+
+func (r *SimulatedRuntime) provision(input fly.LaunchMachineInput) (*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.launchErrs) > 0 {
+		err := r.launchErrs[0]
+		r.launchErrs = r.launchErrs[1:]
+		return nil, err
+	}
+
+	r.nextID++
+
+	var config fly.MachineConfig
+	if input.Config != nil {
+		config = *input.Config
+	}
+
+	machine := &fly.Machine{
+		ID:         fmt.Sprintf("sim%011d", r.nextID),
+		State:      fly.MachineStateCreated,
+		Region:     input.Region,
+		InstanceID: fmt.Sprintf("instance-%d", r.nextID),
+		Config:     &config,
+		ImageRef:   simulatedImageRef(config.Image),
+	}
+	addMachineEvent(machine, JobEventLaunch, fly.MachineStateCreated, simulatorEventSource, time.Now(), nil)
+
+	r.machines[machine.ID] = machine
+
+	return copyMachine(machine), nil
+}
+
+func (r *SimulatedRuntime) MachineGet(ctx context.Context, machineID string) (*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, ok := r.machines[machineID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMachineNotFound, machineID)
+	}
+
+	return copyMachine(machine), nil
+}
+
+func (r *SimulatedRuntime) MachineList(ctx context.Context, state string) ([]*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.listErr != nil {
+		return nil, r.listErr
+	}
+
+	var machines []*fly.Machine
+	for _, machine := range r.machines {
+		// Destroyed machines are omitted from listings, as they are on Fly.
+		if machine.State == fly.MachineStateDestroyed {
+			continue
+		}
+		if state != "" && machine.State != state {
+			continue
+		}
+		machines = append(machines, copyMachine(machine))
+	}
+
+	return machines, nil
+}
+
+func (r *SimulatedRuntime) MachineStop(ctx context.Context, machine *fly.Machine, signal string, timeout time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.machines[machine.ID]
+	if !ok {
+		return nil
+	}
+
+	r.stopSignals[machine.ID] = signal
+
+	if r.ignoreStop[machine.ID] {
+		return nil
+	}
+
+	// Report the exit code a process terminated by a signal would have.
+	r.exit(m, exitRequest(128+int(parseSignal(signal))), time.Now())
+
+	return nil
+}
+
+func (r *SimulatedRuntime) MachineDestroy(ctx context.Context, machine *fly.Machine) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.destroyErr != nil {
+		return r.destroyErr
+	}
+
+	m, ok := r.machines[machine.ID]
+	if !ok {
+		return nil
+	}
+
+	m.State = fly.MachineStateDestroyed
+	addMachineEvent(m, JobEventDestroy, fly.MachineStateDestroyed, simulatorEventSource, time.Now(), nil)
+
+	return nil
+}
+
+func (r *SimulatedRuntime) MachineStart(ctx context.Context, machineID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	machine, ok := r.machines[machineID]
+	if !ok || machine.State == fly.MachineStateDestroyed {
+		return fmt.Errorf("%w: %s", ErrMachineNotFound, machineID)
+	}
+
+	if machine.State != fly.MachineStateStopped {
+		return fmt.Errorf("machine %s is %s", machineID, machine.State)
+	}
+
+	machine.State = fly.MachineStateStarted
+	addMachineEvent(machine, JobEventStart, fly.MachineStateStarted, simulatorEventSource, time.Now(), nil)
+
+	return nil
+}
+
+func (r *SimulatedRuntime) MachineUpdate(ctx context.Context, machine *fly.Machine, config *fly.MachineConfig) (*fly.Machine, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m, ok := r.machines[machine.ID]
+	if !ok || m.State == fly.MachineStateDestroyed {
+		return nil, fmt.Errorf("%w: %s", ErrMachineNotFound, machine.ID)
+	}
+
+	c := *config
+	m.Config = &c
+	m.ImageRef = simulatedImageRef(c.Image)
+	addMachineEvent(m, "update", m.State, simulatorEventSource, time.Now(), nil)
+
+	return copyMachine(m), nil
+}
+
+// ResolveImage resolves the image to the digest its Machines would report.
+func (r *SimulatedRuntime) ResolveImage(ctx context.Context, appName, image string) (string, error) {
+	return simulatedImageRef(image).Digest, nil
+}
+
+func (r *SimulatedRuntime) WaitForExit(ctx context.Context, machineID string, timeout time.Duration) error {
+	return waitForMachineExit(ctx, r, machineID, timeout)
+}
+
+func (r *SimulatedRuntime) exit(machine *fly.Machine, request *fly.MachineRequest, at time.Time) {
+	machine.State = fly.MachineStateStopped
+	addMachineEvent(machine, JobEventExit, fly.MachineStateStopped, simulatorEventSource, at, request)
+
+	if machine.Config != nil && machine.Config.AutoDestroy {
+		machine.State = fly.MachineStateDestroyed
+		addMachineEvent(machine, JobEventDestroy, fly.MachineStateDestroyed, simulatorEventSource, at, nil)
+	}
+}
+
+// simulatedImageRef reports the digest the image is pinned to, or a digest derived from its tag.
+func simulatedImageRef(image string) fly.MachineImageRef {
+	repository, digest, ok := strings.Cut(image, "@")
+	if !ok {
+		sum := sha256.Sum256([]byte(image))
+		digest = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	return fly.MachineImageRef{Repository: repository, Digest: digest}
+}
+
+func copyMachine(machine *fly.Machine) *fly.Machine {
+	c := *machine
+	c.Events = append([]*fly.MachineEvent(nil), machine.Events...)
+	return &c
+}
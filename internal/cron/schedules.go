@@ -2,12 +2,16 @@ package cron
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/sirupsen/logrus"
+	fly "github.com/superfly/fly-go"
 )
 
 const (
@@ -15,10 +19,14 @@ const (
 	executeCommand           = "/usr/local/bin/process-job"
 	cronFilePath             = "/data/crontab"
 	defaultCommandTimeout    = 30
+	defaultStopSignal        = "SIGTERM"
+	defaultKillTimeout       = 10
 )
 
-// SyncSchedules reads schedules from a file and syncs them with the store
-func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, schedulesFilePath string) error {
+// SyncSchedules reads schedules from a file and syncs them with the store.
+// Images of schedules with pin_image set are resolved to a digest with the resolver, which may be nil if no images are pinned.
+// The Machines kept for schedules that are deleted, or no longer reuse their Machine, are destroyed through the runtimes.
+func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, resolver ImageResolver, runtimes RuntimeProvider, schedulesFilePath string) error {
 	if schedulesFilePath == "" {
 		schedulesFilePath = DefaultSchedulesFilePath
 	}
@@ -33,17 +41,23 @@ func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, schedu
 		return fmt.Errorf("failed to list schedules: %w", err)
 	}
 
+	// Validate every schedule before writing any, so an invalid schedule doesn't leave a partial sync
+	for i := range schedules {
+		if err := normalizeSchedule(&schedules[i]); err != nil {
+			return err
+		}
+	}
+
 	// Track present schedules so we know which ones to delete
 	presentSchedules := make(map[string]int)
 
 	for _, schedule := range schedules {
+		record := findScheduleByName(existingSchedules, schedule.Name)
 
-		// Set command timeout to default if not provided
-		if schedule.CommandTimeout == 0 {
-			schedule.CommandTimeout = defaultCommandTimeout
+		if schedule.PinImage {
+			schedule.PinnedImage = resolvePinnedImage(ctx, log, resolver, schedule, record)
 		}
 
-		record := findScheduleByName(existingSchedules, schedule.Name)
 		if record == nil {
 			if err := store.CreateSchedule(ctx, schedule); err != nil {
 				return fmt.Errorf("failed to create schedule: %w", err)
@@ -53,6 +67,13 @@ func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, schedu
 			continue
 		}
 
+		// The kept Machine can't be reused once the schedule stops reusing it, or moves to another app
+		if record.MachineMode == MachineModeReuse && (schedule.MachineMode != MachineModeReuse || schedule.AppName != record.AppName) {
+			if err := releaseScheduleMachine(ctx, log, store, runtimes, *record); err != nil {
+				return err
+			}
+		}
+
 		// If schedule exists, update it
 		if err := store.UpdateSchedule(ctx, schedule); err != nil {
 			return fmt.Errorf("failed to update schedule: %w", err)
@@ -65,6 +86,10 @@ func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, schedu
 	// Delete schedules that are no longer present
 	for _, schedule := range existingSchedules {
 		if _, exists := presentSchedules[schedule.Name]; !exists {
+			if err := releaseScheduleMachine(ctx, log, store, runtimes, schedule); err != nil {
+				return err
+			}
+
 			if err := store.DeleteSchedule(ctx, fmt.Sprint(schedule.ID)); err != nil {
 				return fmt.Errorf("failed to delete schedule: %w", err)
 			}
@@ -72,6 +97,95 @@ func SyncSchedules(ctx context.Context, store *Store, log *logrus.Logger, schedu
 		}
 	}
 
+	if err := store.RecordEvent(ctx, Event{Type: EventSchedulesSynced}); err != nil {
+		log.WithError(err).Warn("failed to record schedules synced event")
+	}
+
+	return nil
+}
+
+// releaseScheduleMachine destroys the Machine kept for a schedule in reuse mode and forgets it.
+// The record is kept if the Machine can't be destroyed, so it isn't orphaned.
+func releaseScheduleMachine(ctx context.Context, log *logrus.Logger, store *Store, runtimes RuntimeProvider, schedule Schedule) error {
+	record, err := store.FindScheduleMachine(ctx, schedule.ID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("failed to find machine of schedule %s: %w", schedule.Name, err)
+	}
+
+	if runtimes == nil {
+		return fmt.Errorf("machine %s of schedule %s can not be destroyed without a runtime", record.MachineID, schedule.Name)
+	}
+
+	runtime, err := runtimes.Runtime(ctx, schedule.AppName)
+	if err != nil {
+		return fmt.Errorf("failed to create runtime: %w", err)
+	}
+
+	if err := runtime.MachineDestroy(ctx, &fly.Machine{ID: record.MachineID}); err != nil && !errors.Is(err, ErrMachineNotFound) {
+		return fmt.Errorf("failed to destroy machine %s of schedule %s: %w", record.MachineID, schedule.Name, err)
+	}
+
+	log.Infof("Destroyed machine %s of schedule %s", record.MachineID, schedule.Name)
+
+	return store.DeleteScheduleMachine(ctx, schedule.ID)
+}
+
+// normalizeSchedule applies the defaults of a schedule read from the schedules file and validates it.
+func normalizeSchedule(schedule *Schedule) error {
+	// Set command timeout to default if not provided
+	if schedule.CommandTimeout == 0 {
+		schedule.CommandTimeout = defaultCommandTimeout
+	}
+
+	if schedule.StopSignal == "" {
+		schedule.StopSignal = defaultStopSignal
+	}
+
+	if schedule.KillTimeout == 0 {
+		schedule.KillTimeout = defaultKillTimeout
+	}
+
+	switch schedule.RegionStrategy {
+	case "":
+		schedule.RegionStrategy = RegionStrategyOrderedFailover
+	case RegionStrategyOrderedFailover, RegionStrategyRandom:
+	default:
+		return fmt.Errorf("schedule %s has an invalid region_strategy %q", schedule.Name, schedule.RegionStrategy)
+	}
+
+	if err := schedule.Retention.Validate(); err != nil {
+		return fmt.Errorf("schedule %s has an invalid retention policy: %w", schedule.Name, err)
+	}
+
+	if err := schedule.SLO.Validate(); err != nil {
+		return fmt.Errorf("schedule %s has an invalid slo: %w", schedule.Name, err)
+	}
+
+	for _, window := range schedule.Blackout {
+		if err := window.Validate(); err != nil {
+			return fmt.Errorf("schedule %s has an invalid blackout window: %w", schedule.Name, err)
+		}
+	}
+
+	if _, err := schedule.jitter(0); err != nil {
+		return fmt.Errorf("schedule %s has an invalid jitter: %w", schedule.Name, err)
+	}
+
+	if _, err := crontabEntries(*schedule); err != nil {
+		return fmt.Errorf("schedule %s has an invalid schedule: %w", schedule.Name, err)
+	}
+
+	switch schedule.MachineMode {
+	case "":
+		schedule.MachineMode = MachineModeEphemeral
+	case MachineModeEphemeral, MachineModeReuse:
+	default:
+		return fmt.Errorf("schedule %s has an invalid machine_mode %q", schedule.Name, schedule.MachineMode)
+	}
+
 	return nil
 }
 
@@ -89,10 +203,20 @@ func SyncCrontab(ctx context.Context, store *Store, log *logrus.Logger) error {
 	defer func() { _ = file.Close() }()
 
 	for _, schedule := range schedules {
-		entry := fmt.Sprintf("%s %s %d\n", schedule.Schedule, executeCommand, schedule.ID)
-		_, err := file.WriteString(entry)
+		entries, err := crontabEntries(schedule)
 		if err != nil {
-			return fmt.Errorf("failed to write to crontab file: %w", err)
+			log.WithError(err).Errorf("Skipping schedule %s, it can not be run by cron", schedule.Name)
+			continue
+		}
+
+		if _, err := ParseCronExpression(schedule.Schedule); err != nil {
+			log.WithError(err).Warnf("Schedule %s is passed to cron as is, its next runs can't be computed or checked by the watchdog", schedule.Name)
+		}
+
+		for _, entry := range entries {
+			if _, err := file.WriteString(entry + "\n"); err != nil {
+				return fmt.Errorf("failed to write to crontab file: %w", err)
+			}
 		}
 	}
 
@@ -105,6 +229,59 @@ func SyncCrontab(ctx context.Context, store *Store, log *logrus.Logger) error {
 	return file.Sync()
 }
 
+// crontabEntries returns the crontab entries that trigger the schedule's jobs.
+// Expressions in the standard syntax that can't be parsed, such as @reboot or extensions of other cron
+// implementations, are passed to cron as is. Only the seconds field and @every intervals must be parsed.
+func crontabEntries(schedule Schedule) ([]string, error) {
+	command := fmt.Sprintf("%s %d", executeCommand, schedule.ID)
+
+	expr, err := ParseCronExpression(schedule.Schedule)
+	if err != nil {
+		if !isStandardCronSyntax(schedule.Schedule) {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s %s", strings.Join(strings.Fields(schedule.Schedule), " "), command)}, nil
+	}
+
+	return expr.CrontabEntries(command)
+}
+
+// isStandardCronSyntax reports whether the expression has the 5 fields of the crontab, or is a macro other than @every.
+func isStandardCronSyntax(expr string) bool {
+	fields := strings.Fields(expr)
+
+	if len(fields) == 1 && strings.HasPrefix(fields[0], "@") && fields[0] != "@every" {
+		return true
+	}
+
+	return len(fields) == 5 && !strings.HasPrefix(fields[0], "@")
+}
+
+// resolvePinnedImage resolves the schedule's image to a digest.
+// If the image can not be resolved, the previously pinned image is kept as long as the image is unchanged.
+func resolvePinnedImage(ctx context.Context, log *logrus.Logger, resolver ImageResolver, schedule Schedule, record *Schedule) string {
+	if resolver == nil {
+		log.Warnf("Schedule %s has pin_image set, but images can not be resolved by this backend", schedule.Name)
+		return ""
+	}
+
+	digest, err := resolver.ResolveImage(ctx, schedule.AppName, schedule.Config.Image)
+	if err != nil {
+		if record != nil && record.PinnedImage != "" && record.Config.Image == schedule.Config.Image {
+			log.Warnf("Failed to resolve image for schedule %s, keeping %s: %s", schedule.Name, record.PinnedImage, err)
+			return record.PinnedImage
+		}
+
+		log.Warnf("Failed to resolve image for schedule %s, jobs will use %s: %s", schedule.Name, schedule.Config.Image, err)
+		return ""
+	}
+
+	pinned := pinImage(schedule.Config.Image, digest)
+	log.Infof("Pinned schedule %s to %s", schedule.Name, pinned)
+
+	return pinned
+}
+
 func findScheduleByName(schedules []Schedule, name string) *Schedule {
 	for _, schedule := range schedules {
 		if schedule.Name == name {
@@ -2,6 +2,9 @@ package cron
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"os"
 	"testing"
 
@@ -24,6 +27,8 @@ const testData = `[
         "region": "iad",
         "command": "uptime",
 		"command_timeout": 60,
+        "stop_signal": "SIGINT",
+        "kill_timeout": 5,
         "enabled": true,
         "config": {
             "auto_destroy": true,
@@ -72,6 +77,11 @@ const testData2 = `[
         "region": "ord",
         "command": "uptime",
 		"command_timeout": 60,
+        "stop_signal": "SIGINT",
+        "kill_timeout": 5,
+        "regions": ["ord", "ewr"],
+        "region_strategy": "random",
+        "machine_mode": "reuse",
         "enabled": true,
         "config": {
             "auto_destroy": true,
@@ -136,6 +146,8 @@ func TestReadSchedulesFromFile(t *testing.T) {
 			Command:        "uptime",
 			CommandTimeout: 60,
 			Enabled:        true,
+			StopSignal:     "SIGINT",
+			KillTimeout:    5,
 			Config: fly.MachineConfig{
 				AutoDestroy: true,
 				Guest: &fly.MachineGuest{
@@ -194,7 +206,7 @@ func TestSyncSchedules(t *testing.T) {
 		}
 		defer func() { _ = os.Remove(schedulesFile.Name()) }()
 
-		if err := SyncSchedules(context.TODO(), store, log, schedulesFile.Name()); err != nil {
+		if err := SyncSchedules(context.TODO(), store, log, nil, nil, schedulesFile.Name()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -217,6 +229,10 @@ func TestSyncSchedules(t *testing.T) {
 				Command:        "uptime",
 				CommandTimeout: 60,
 				Enabled:        true,
+				StopSignal:     "SIGINT",
+				KillTimeout:    5,
+				RegionStrategy: "ordered-failover",
+				MachineMode:    "ephemeral",
 				Config: fly.MachineConfig{
 					AutoDestroy: true,
 					Guest: &fly.MachineGuest{
@@ -240,6 +256,10 @@ func TestSyncSchedules(t *testing.T) {
 				Command:        "uptime",
 				CommandTimeout: 30,
 				Enabled:        false,
+				StopSignal:     "SIGTERM",
+				KillTimeout:    10,
+				RegionStrategy: "ordered-failover",
+				MachineMode:    "ephemeral",
 				Config: fly.MachineConfig{
 					AutoDestroy: true,
 					Guest: &fly.MachineGuest{
@@ -267,7 +287,7 @@ func TestSyncSchedules(t *testing.T) {
 		}
 		defer func() { _ = os.Remove(originalFile.Name()) }()
 
-		if err := SyncSchedules(context.TODO(), store, log, originalFile.Name()); err != nil {
+		if err := SyncSchedules(context.TODO(), store, log, nil, nil, originalFile.Name()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -277,7 +297,7 @@ func TestSyncSchedules(t *testing.T) {
 		}
 		defer func() { _ = os.Remove(schedulesFile.Name()) }()
 
-		if err := SyncSchedules(context.TODO(), store, log, schedulesFile.Name()); err != nil {
+		if err := SyncSchedules(context.TODO(), store, log, nil, nil, schedulesFile.Name()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -300,6 +320,11 @@ func TestSyncSchedules(t *testing.T) {
 				Command:        "uptime",
 				CommandTimeout: 60,
 				Enabled:        true,
+				StopSignal:     "SIGINT",
+				KillTimeout:    5,
+				Regions:        []string{"ord", "ewr"},
+				RegionStrategy: "random",
+				MachineMode:    "reuse",
 				Config: fly.MachineConfig{
 					AutoDestroy: true,
 					Guest: &fly.MachineGuest{
@@ -323,6 +348,10 @@ func TestSyncSchedules(t *testing.T) {
 				Command:        "uptime",
 				CommandTimeout: 30,
 				Enabled:        false,
+				StopSignal:     "SIGTERM",
+				KillTimeout:    10,
+				RegionStrategy: "ordered-failover",
+				MachineMode:    "ephemeral",
 				Config: fly.MachineConfig{
 					AutoDestroy: true,
 					Guest: &fly.MachineGuest{
@@ -350,7 +379,7 @@ func TestSyncSchedules(t *testing.T) {
 		}
 		defer func() { _ = os.Remove(schedulesFile.Name()) }()
 
-		if err := SyncSchedules(context.TODO(), store, log, schedulesFile.Name()); err != nil {
+		if err := SyncSchedules(context.TODO(), store, log, nil, nil, schedulesFile.Name()); err != nil {
 			t.Fatal(err)
 		}
 
@@ -363,6 +392,107 @@ func TestSyncSchedules(t *testing.T) {
 			t.Fatalf("expected 0 schedules, got %d", len(schedules))
 		}
 	})
+
+	t.Run("rejects invalid schedules without syncing any", func(t *testing.T) {
+		schedulesFile, err := createSchedulesFile([]byte(`[
+			{"name": "valid-check", "app_name": "shaun-pg-flex", "schedule": "* * * * *", "region": "iad", "command": "uptime", "enabled": true},
+			{"name": "invalid-check", "app_name": "shaun-pg-flex", "schedule": "* * * * *", "region": "iad", "command": "uptime", "region_strategy": "closest"}
+		]`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = os.Remove(schedulesFile.Name()) }()
+
+		if err := SyncSchedules(context.TODO(), store, log, nil, nil, schedulesFile.Name()); err == nil {
+			t.Fatal("expected an error")
+		}
+
+		schedules, err := store.ListSchedules(context.TODO())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(schedules) != 0 {
+			t.Fatalf("expected no schedules to be synced, got %d", len(schedules))
+		}
+	})
+
+	t.Run("destroys the machines of schedules leaving reuse mode", func(t *testing.T) {
+		runtime := NewSimulatedRuntime()
+
+		syncReuseTestSchedule := func(mode string) {
+			t.Helper()
+
+			data := `[]`
+			if mode != "" {
+				data = fmt.Sprintf(`[{"name": "reuse-check", "app_name": "shaun-pg-flex", "schedule": "* * * * *", "region": "iad", "command": "uptime", "enabled": true, "machine_mode": %q}]`, mode)
+			}
+
+			schedulesFile, err := createSchedulesFile([]byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() { _ = os.Remove(schedulesFile.Name()) }()
+
+			if err := SyncSchedules(context.TODO(), store, log, nil, runtime, schedulesFile.Name()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		keepTestMachine := func() string {
+			t.Helper()
+
+			schedule, err := store.FindScheduleByName(context.TODO(), "reuse-check")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			machine, err := runtime.MachineProvision(context.TODO(), fly.LaunchMachineInput{Region: "iad"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := store.SetScheduleMachine(context.TODO(), schedule.ID, machine.ID, "hash"); err != nil {
+				t.Fatal(err)
+			}
+
+			return machine.ID
+		}
+
+		assertDestroyed := func(machineID string) {
+			t.Helper()
+
+			machine, err := runtime.MachineGet(context.TODO(), machineID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if machine.State != fly.MachineStateDestroyed {
+				t.Fatalf("expected machine %s to be destroyed, got %s", machineID, machine.State)
+			}
+		}
+
+		syncReuseTestSchedule(MachineModeReuse)
+		machineID := keepTestMachine()
+
+		syncReuseTestSchedule(MachineModeEphemeral)
+		assertDestroyed(machineID)
+
+		schedule, err := store.FindScheduleByName(context.TODO(), "reuse-check")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.FindScheduleMachine(context.TODO(), schedule.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected the machine of the schedule to be forgotten, got %v", err)
+		}
+
+		syncReuseTestSchedule(MachineModeReuse)
+		machineID = keepTestMachine()
+
+		syncReuseTestSchedule("")
+		assertDestroyed(machineID)
+	})
 }
 
 func createSchedulesFile(schedules []byte) (*os.File, error) {
@@ -0,0 +1,139 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultStatsWindow is the period schedule statistics are computed over when no window is given.
+const DefaultStatsWindow = 7 * 24 * time.Hour
+
+// SLO describes the objectives a schedule is expected to meet within the stats window.
+type SLO struct {
+	// MaxDuration is a duration such as 5m that no job should run for longer than, days may be specified as e.g. 1d.
+	MaxDuration string `json:"max_duration,omitempty"`
+	// MinSuccessRate is the minimum fraction, between 0 and 1, of jobs that should complete.
+	MinSuccessRate float64 `json:"min_success_rate,omitempty"`
+}
+
+func (s SLO) Validate() error {
+	if _, err := s.maxDuration(); err != nil {
+		return err
+	}
+
+	if s.MinSuccessRate < 0 || s.MinSuccessRate > 1 {
+		return fmt.Errorf("min_success_rate must be between 0 and 1")
+	}
+
+	return nil
+}
+
+func (s SLO) maxDuration() (time.Duration, error) {
+	if s.MaxDuration == "" {
+		return 0, nil
+	}
+
+	d, err := ParseDuration(s.MaxDuration)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_duration %q: %w", s.MaxDuration, err)
+	}
+
+	return d, nil
+}
+
+// ScheduleStats summarizes how the jobs of a schedule performed within a window.
+// Cancelled jobs are left out, as are jobs that are still active. Durations are in seconds.
+type ScheduleStats struct {
+	ScheduleID   int     `json:"schedule_id"`
+	ScheduleName string  `json:"schedule_name"`
+	Window       string  `json:"window"`
+	Jobs         int     `json:"jobs"`
+	Succeeded    int     `json:"succeeded"`
+	Failed       int     `json:"failed"`
+	SuccessRate  float64 `json:"success_rate"`
+	P50Duration  float64 `json:"p50_duration"`
+	P95Duration  float64 `json:"p95_duration"`
+	MaxDuration  float64 `json:"max_duration"`
+	// LastSuccessAt, LastFailureAt and FailureStreak consider every job of the schedule, regardless of the window.
+	LastSuccessAt *time.Time `json:"last_success_at"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	FailureStreak int        `json:"failure_streak"`
+	// SLOViolations describes each objective of the schedule's SLO that was not met.
+	SLOViolations []string `json:"slo_violations"`
+}
+
+// ComputeScheduleStats computes the statistics of a schedule over the given window, and checks them against its SLO.
+func ComputeScheduleStats(ctx context.Context, store *Store, schedule Schedule, window time.Duration) (*ScheduleStats, error) {
+	since := time.Now().Add(-window)
+
+	outcomes, err := store.CountJobOutcomes(ctx, schedule.ID, since)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ScheduleStats{
+		ScheduleID:    schedule.ID,
+		ScheduleName:  schedule.Name,
+		Window:        window.String(),
+		Jobs:          outcomes.Succeeded + outcomes.Failed,
+		Succeeded:     outcomes.Succeeded,
+		Failed:        outcomes.Failed,
+		MaxDuration:   outcomes.MaxDuration,
+		SLOViolations: []string{},
+	}
+
+	if stats.Jobs > 0 {
+		stats.SuccessRate = float64(stats.Succeeded) / float64(stats.Jobs)
+	}
+
+	if stats.P50Duration, err = store.JobDurationPercentile(ctx, schedule.ID, since, 0.5, outcomes.Finished); err != nil {
+		return nil, err
+	}
+
+	if stats.P95Duration, err = store.JobDurationPercentile(ctx, schedule.ID, since, 0.95, outcomes.Finished); err != nil {
+		return nil, err
+	}
+
+	lastSuccess, err := store.LastJobFinishedAt(ctx, schedule.ID, JobStatusCompleted)
+	if err != nil {
+		return nil, err
+	}
+	if lastSuccess.Valid {
+		stats.LastSuccessAt = &lastSuccess.Time
+	}
+
+	lastFailure, err := store.LastJobFinishedAt(ctx, schedule.ID, failedJobStatuses...)
+	if err != nil {
+		return nil, err
+	}
+	if lastFailure.Valid {
+		stats.LastFailureAt = &lastFailure.Time
+	}
+
+	if stats.FailureStreak, err = store.JobFailureStreak(ctx, schedule.ID); err != nil {
+		return nil, err
+	}
+
+	maxDuration, err := schedule.SLO.maxDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	if maxDuration > 0 {
+		exceeded, err := store.CountJobsLongerThan(ctx, schedule.ID, since, maxDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		if exceeded > 0 {
+			stats.SLOViolations = append(stats.SLOViolations, fmt.Sprintf("%d job(s) ran longer than the max_duration of %s", exceeded, schedule.SLO.MaxDuration))
+		}
+	}
+
+	if schedule.SLO.MinSuccessRate > 0 && stats.Jobs > 0 && stats.SuccessRate < schedule.SLO.MinSuccessRate {
+		stats.SLOViolations = append(stats.SLOViolations, fmt.Sprintf("success rate of %.1f%% is below the min_success_rate of %.1f%%", stats.SuccessRate*100, schedule.SLO.MinSuccessRate*100))
+	}
+
+	return stats, nil
+}
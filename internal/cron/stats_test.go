@@ -0,0 +1,89 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestComputeScheduleStats(t *testing.T) {
+	ctx := context.TODO()
+
+	store, _, schedule := setupJobTest(t)
+
+	addJob := func(status string, age, duration time.Duration) {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status == JobStatusCompleted {
+			_, err = store.CompleteJob(ctx, job.ID, 0, "")
+		} else {
+			_, err = store.FailJob(ctx, job.ID, 1, "", "")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Time spent queued and launching isn't part of the duration
+		createdAt := time.Now().Add(-age)
+		startedAt := createdAt.Add(time.Minute)
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ?, finished_at = ? WHERE id = ?", createdAt, startedAt.Add(duration), job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		start := JobEvent{JobID: job.ID, Type: JobEventStart, Source: "test", Timestamp: startedAt}
+		if err := store.RecordJobEvents(ctx, []JobEvent{start}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Outside of the window
+	addJob(JobStatusFailed, 30*24*time.Hour, time.Hour)
+
+	addJob(JobStatusCompleted, 6*time.Hour, 10*time.Second)
+	addJob(JobStatusCompleted, 5*time.Hour, 20*time.Second)
+	addJob(JobStatusFailed, 4*time.Hour, 40*time.Second)
+	addJob(JobStatusCompleted, 3*time.Hour, 30*time.Second)
+	addJob(JobStatusFailed, 2*time.Hour, 5*time.Second)
+	addJob(JobStatusFailed, time.Hour, 5*time.Second)
+
+	schedule.SLO = SLO{MaxDuration: "30s", MinSuccessRate: 0.9}
+
+	stats, err := ComputeScheduleStats(ctx, store, *schedule, DefaultStatsWindow)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stats.Jobs != 6 || stats.Succeeded != 3 || stats.Failed != 3 {
+		t.Fatalf("expected 3 of 6 jobs to succeed, got %d of %d", stats.Succeeded, stats.Jobs)
+	}
+
+	if stats.SuccessRate != 0.5 {
+		t.Fatalf("expected a success rate of 0.5, got %v", stats.SuccessRate)
+	}
+
+	for name, pair := range map[string][2]float64{
+		"p50": {stats.P50Duration, 10},
+		"p95": {stats.P95Duration, 40},
+		"max": {stats.MaxDuration, 40},
+	} {
+		if pair[0] < pair[1]-0.01 || pair[0] > pair[1]+0.01 {
+			t.Fatalf("expected %s duration of %vs, got %vs", name, pair[1], pair[0])
+		}
+	}
+
+	if stats.FailureStreak != 2 {
+		t.Fatalf("expected a failure streak of 2, got %d", stats.FailureStreak)
+	}
+
+	if stats.LastSuccessAt == nil || stats.LastFailureAt == nil || !stats.LastFailureAt.After(*stats.LastSuccessAt) {
+		t.Fatalf("expected the last failure to follow the last success, got %v and %v", stats.LastFailureAt, stats.LastSuccessAt)
+	}
+
+	if len(stats.SLOViolations) != 2 {
+		t.Fatalf("expected both objectives to be violated, got %v", stats.SLOViolations)
+	}
+}
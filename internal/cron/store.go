@@ -3,8 +3,12 @@ package cron
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/jmoiron/sqlx"
@@ -21,6 +25,30 @@ const (
 	JobStatusRunning   = "running"
 	JobStatusCompleted = "completed"
 	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+	JobStatusTimedOut  = "timed_out"
+	JobStatusLost      = "lost"
+	JobStatusSkipped   = "skipped"
+
+	// Failure reasons describe why a job ended in a failed, timed_out or lost state.
+	FailureReasonExitCode        = "non_zero_exit"
+	FailureReasonLaunchFailed    = "launch_failed"
+	FailureReasonCommandTimeout  = "command_timeout"
+	FailureReasonMachineNotFound = "machine_not_found"
+	FailureReasonMissingExit     = "missing_exit_event"
+	FailureReasonUnknownExit     = "unknown_exit"
+
+	// Skip reasons describe why a job was skipped rather than launched.
+	SkipReasonPaused   = "paused"
+	SkipReasonBlackout = "blackout"
+
+	// Region strategies determine the order in which a schedule's regions are attempted.
+	RegionStrategyOrderedFailover = "ordered-failover"
+	RegionStrategyRandom          = "random"
+
+	// Machine modes determine whether each job launches a new Machine, or starts the schedule's stopped Machine.
+	MachineModeEphemeral = "ephemeral"
+	MachineModeReuse     = "reuse"
 )
 
 type Schedule struct {
@@ -33,6 +61,30 @@ type Schedule struct {
 	Region         string            `json:"region" db:"region"`
 	Enabled        bool              `json:"enabled" db:"enabled"`
 	Config         fly.MachineConfig `json:"config" db:"config"`
+	// StopSignal is sent to the Machine when the command exceeds its timeout.
+	StopSignal string `json:"stop_signal" db:"stop_signal"`
+	// KillTimeout is the number of seconds to wait after the stop signal before the Machine is destroyed.
+	KillTimeout int `json:"kill_timeout" db:"kill_timeout"`
+	// Regions are attempted in turn when a Machine can not be launched, taking precedence over Region.
+	Regions []string `json:"regions" db:"regions"`
+	// RegionStrategy is either ordered-failover or random.
+	RegionStrategy string `json:"region_strategy" db:"region_strategy"`
+	// MachineMode is either ephemeral or reuse.
+	MachineMode string `json:"machine_mode" db:"machine_mode"`
+	// PinImage resolves the image to a digest when schedules are synced, so every job runs the same image until the next sync.
+	PinImage bool `json:"pin_image" db:"pin_image"`
+	// PinnedImage is the image reference by digest that jobs are launched with when PinImage is set.
+	PinnedImage string `json:"pinned_image" db:"pinned_image"`
+	// Retention overrides the global job retention policy for the schedule.
+	Retention Retention `json:"retention" db:"retention"`
+	// SLO sets the objectives the schedule's run statistics are checked against.
+	SLO SLO `json:"slo" db:"slo"`
+	// Jitter is the longest a scheduled job may be delayed before its Machine is launched, overriding JOB_SPREAD.
+	Jitter string `json:"jitter" db:"jitter"`
+	// Priority orders the schedule's queued jobs ahead of those of schedules with a lower priority.
+	Priority int `json:"priority" db:"priority"`
+	// Blackout lists the windows during which the schedule's runs are skipped.
+	Blackout []BlackoutWindow `json:"blackout" db:"blackout"`
 }
 
 // TODO - Remove this
@@ -46,6 +98,28 @@ type RawSchedule struct {
 	Region         string `json:"region" db:"region"`
 	Enabled        bool   `json:"enabled" db:"enabled"`
 	Config         string `json:"config" db:"config"` // JSON string
+	StopSignal     string `json:"stop_signal" db:"stop_signal"`
+	KillTimeout    int    `json:"kill_timeout" db:"kill_timeout"`
+	Regions        string `json:"regions" db:"regions"` // JSON string
+	RegionStrategy string `json:"region_strategy" db:"region_strategy"`
+	MachineMode    string `json:"machine_mode" db:"machine_mode"`
+	PinImage       bool   `json:"pin_image" db:"pin_image"`
+	PinnedImage    string `json:"pinned_image" db:"pinned_image"`
+	Retention      string `json:"retention" db:"retention"` // JSON string
+	SLO            string `json:"slo" db:"slo"`             // JSON string
+	Jitter         string `json:"jitter" db:"jitter"`
+	Priority       int    `json:"priority" db:"priority"`
+	Blackout       string `json:"blackout" db:"blackout"` // JSON string
+}
+
+// ScheduleMachine is the stopped Machine a schedule in reuse mode starts for each job.
+type ScheduleMachine struct {
+	ScheduleID int    `json:"schedule_id" db:"schedule_id"`
+	MachineID  string `json:"machine_id" db:"machine_id"`
+	// ConfigHash identifies the config the Machine was last launched or updated with.
+	ConfigHash string    `json:"config_hash" db:"config_hash"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
 type Job struct {
@@ -59,6 +133,83 @@ type Job struct {
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
 	FinishedAt sql.NullTime   `json:"finished_at" db:"finished_at"`
+	// FailureReason is a machine readable explanation of why a job did not complete.
+	FailureReason sql.NullString `json:"failure_reason" db:"failure_reason"`
+	// StopRequestedAt is set once the Machine has been asked to stop after exceeding its timeout.
+	StopRequestedAt sql.NullTime `json:"stop_requested_at" db:"stop_requested_at"`
+	// Region is the region the Machine was launched in.
+	Region sql.NullString `json:"region" db:"region"`
+	// LaunchAttempts records each failed attempt to launch the Machine.
+	LaunchAttempts LaunchAttempts `json:"launch_attempts" db:"launch_attempts"`
+	// ImageDigest is the digest of the image the Machine ran, as reported by the Machine.
+	ImageDigest sql.NullString `json:"image_digest" db:"image_digest"`
+	// ConfigSnapshot is the JSON encoded Machine config the job was launched with.
+	ConfigSnapshot sql.NullString `json:"config_snapshot" db:"config_snapshot"`
+	// MachineState and MachineEvents are the last known state and JSON encoded events of the Machine.
+	// They outlive the Machine, which is only queryable for a limited time after it is destroyed.
+	MachineState  sql.NullString `json:"machine_state" db:"machine_state"`
+	MachineEvents sql.NullString `json:"machine_events" db:"machine_events"`
+	// RerunOf is the job this job re-ran.
+	RerunOf sql.NullInt64 `json:"rerun_of" db:"rerun_of"`
+	// JitterDelay is the number of seconds the job waited before its Machine was launched.
+	JitterDelay int `json:"jitter_delay" db:"jitter_delay"`
+	// QueuedAt is when the job joined the launch queue, and DispatchedAt when it left the queue to be launched.
+	QueuedAt     sql.NullTime `json:"queued_at" db:"queued_at"`
+	DispatchedAt sql.NullTime `json:"dispatched_at" db:"dispatched_at"`
+	// NotBefore is when the job's jitter delay has passed, it stays queued until then.
+	NotBefore sql.NullTime `json:"not_before" db:"not_before"`
+	// Scheduled is set for jobs triggered by their schedule, rather than by hand or as a re-run.
+	Scheduled bool `json:"scheduled" db:"scheduled"`
+}
+
+// MachineConfig decodes the config snapshot of the job.
+func (j Job) MachineConfig() (*fly.MachineConfig, error) {
+	if !j.ConfigSnapshot.Valid {
+		return nil, fmt.Errorf("job %d has no config snapshot", j.ID)
+	}
+
+	var cfg fly.MachineConfig
+	if err := json.Unmarshal([]byte(j.ConfigSnapshot.String), &cfg); err != nil {
+		return nil, fmt.Errorf("error unmarshaling config snapshot: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+type LaunchAttempt struct {
+	Region    string    `json:"region"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// LaunchAttempts is stored as a JSON array.
+type LaunchAttempts []LaunchAttempt
+
+func (a *LaunchAttempts) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*a = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), a)
+	case []byte:
+		return json.Unmarshal(v, a)
+	default:
+		return fmt.Errorf("unsupported launch attempts type %T", src)
+	}
+}
+
+func (a LaunchAttempts) Value() (driver.Value, error) {
+	if len(a) == 0 {
+		return nil, nil
+	}
+
+	b, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(b), nil
 }
 
 type Store struct {
@@ -177,6 +328,92 @@ func (s Store) ListJobs(ctx context.Context, scheduleID string, limit int) ([]Jo
 	return jobs, nil
 }
 
+// Job sort orders supported by ListJobsFiltered.
+const (
+	JobSortCreated  = "created"
+	JobSortDuration = "duration"
+)
+
+// JobFilter narrows down and pages the jobs returned by ListJobsFiltered. Zero values are not filtered on.
+type JobFilter struct {
+	ScheduleID int
+	Statuses   []string
+	// Since and Until bound the time jobs were created.
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+	// Sort is either created or duration, newest or longest first unless Ascending is set.
+	// Jobs that have not finished are sorted last by duration.
+	Sort      string
+	Ascending bool
+}
+
+func (s Store) ListJobsFiltered(ctx context.Context, filter JobFilter) ([]Job, error) {
+	var (
+		conditions []string
+		args       []any
+	)
+
+	if filter.ScheduleID != 0 {
+		conditions = append(conditions, "schedule_id = ?")
+		args = append(args, filter.ScheduleID)
+	}
+
+	if len(filter.Statuses) > 0 {
+		conditions = append(conditions, "status IN (?)")
+		args = append(args, filter.Statuses)
+	}
+
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "julianday(created_at) >= julianday(?)")
+		args = append(args, filter.Since)
+	}
+
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "julianday(created_at) <= julianday(?)")
+		args = append(args, filter.Until)
+	}
+
+	query := "SELECT * FROM jobs"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	direction := "DESC"
+	if filter.Ascending {
+		direction = "ASC"
+	}
+
+	switch filter.Sort {
+	case "", JobSortCreated:
+		query += fmt.Sprintf(" ORDER BY julianday(created_at) %s, id %s", direction, direction)
+	case JobSortDuration:
+		query += fmt.Sprintf(" ORDER BY %s %s NULLS LAST, id %s", jobDurationSQL, direction, direction)
+	default:
+		return nil, fmt.Errorf("unsupported sort %q", filter.Sort)
+	}
+
+	query += " LIMIT ? OFFSET ?"
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit, filter.Offset)
+
+	query, args, err := sqlx.In(query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs []Job
+	if err := s.SelectContext(ctx, &jobs, query, args...); err != nil {
+		return nil, fmt.Errorf("error getting jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
 func (s Store) ListJobsByStatus(ctx context.Context, status string) ([]Job, error) {
 	var jobs []Job
 	if err := s.DB.SelectContext(ctx, &jobs, "SELECT * FROM jobs WHERE status = ?", status); err != nil {
@@ -186,6 +423,115 @@ func (s Store) ListJobsByStatus(ctx context.Context, status string) ([]Job, erro
 	return jobs, nil
 }
 
+// MarkJobScheduled records that the job was triggered by its schedule, so it's skipped if the schedule is paused.
+func (s Store) MarkJobScheduled(ctx context.Context, id int) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET scheduled = ?, updated_at = ? WHERE id = ?",
+		true,
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// QueueJob adds the pending job to the launch queue.
+func (s Store) QueueJob(ctx context.Context, id int) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET queued_at = ?, updated_at = ? WHERE id = ?",
+		time.Now(),
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// ListQueuedJobs returns the jobs waiting in the launch queue, in the order they are to be launched:
+// by the priority of their schedule, highest first, then the order they were queued in.
+// Jobs still waiting out their jitter delay are left out.
+func (s Store) ListQueuedJobs(ctx context.Context) ([]Job, error) {
+	var jobs []Job
+	err := s.SelectContext(ctx, &jobs, `SELECT jobs.* FROM jobs
+		JOIN schedules ON schedules.id = jobs.schedule_id
+		WHERE jobs.status = ? AND jobs.queued_at IS NOT NULL AND jobs.dispatched_at IS NULL
+		AND (jobs.not_before IS NULL OR julianday(jobs.not_before) <= julianday(?))
+		ORDER BY schedules.priority DESC, julianday(jobs.queued_at), jobs.id`,
+		JobStatusPending,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting queued jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// DispatchJob takes the queued job off the launch queue, provided fewer than maxJobs jobs are being launched or
+// running overall, and fewer than maxAppJobs for the app. A limit of 0 is unlimited. Returns false if the job
+// could not be dispatched, either due to the limits or because it's no longer queued.
+// The limits are checked and the job dispatched in a single statement, so concurrent dispatchers can't exceed them.
+func (s Store) DispatchJob(ctx context.Context, id int, appName string, maxJobs, maxAppJobs int) (bool, error) {
+	result, err := s.ExecContext(ctx, `UPDATE jobs SET dispatched_at = ?, updated_at = ?
+		WHERE id = ? AND status = ? AND queued_at IS NOT NULL AND dispatched_at IS NULL
+		AND (? = 0 OR (SELECT COUNT(*) FROM jobs
+			WHERE status IN (?, ?) AND dispatched_at IS NOT NULL) < ?)
+		AND (? = 0 OR (SELECT COUNT(*) FROM jobs
+			JOIN schedules ON schedules.id = jobs.schedule_id
+			WHERE schedules.app_name = ? AND jobs.status IN (?, ?) AND jobs.dispatched_at IS NOT NULL) < ?)`,
+		time.Now(),
+		time.Now(),
+		id,
+		JobStatusPending,
+		maxJobs,
+		JobStatusPending,
+		JobStatusRunning,
+		maxJobs,
+		maxAppJobs,
+		appName,
+		JobStatusPending,
+		JobStatusRunning,
+		maxAppJobs,
+	)
+	if err != nil {
+		return false, fmt.Errorf("error dispatching job: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+// ListStalledLaunches returns the pending jobs dispatched before the given time that still have no Machine.
+func (s Store) ListStalledLaunches(ctx context.Context, dispatchedBefore time.Time) ([]Job, error) {
+	var jobs []Job
+	err := s.SelectContext(ctx, &jobs, `SELECT * FROM jobs
+		WHERE status = ? AND dispatched_at IS NOT NULL AND machine_id IS NULL
+		AND julianday(dispatched_at) < julianday(?)
+		ORDER BY id`,
+		JobStatusPending,
+		dispatchedBefore,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error getting stalled launches: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// FailStalledLaunch fails a dispatched job whose Machine was never recorded, returning false if the job
+// has since been given a Machine or is no longer pending.
+func (s Store) FailStalledLaunch(ctx context.Context, id int, stderr string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, exit_code = ?, failure_reason = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ? AND dispatched_at IS NOT NULL AND machine_id IS NULL",
+		JobStatusFailed,
+		1,
+		FailureReasonLaunchFailed,
+		stderr,
+		time.Now(),
+		time.Now(),
+		id,
+	)
+}
+
 func (s Store) ListReconcilableJobs(ctx context.Context) ([]Job, error) {
 	var jobs []Job
 	if err := s.DB.SelectContext(ctx, &jobs, "SELECT * FROM jobs WHERE status IN (?,?)", JobStatusPending, JobStatusRunning); err != nil {
@@ -195,13 +541,295 @@ func (s Store) ListReconcilableJobs(ctx context.Context) ([]Job, error) {
 	return jobs, nil
 }
 
+// ListFinishedJobs returns the jobs of a schedule that are no longer active, newest first.
+// Only the columns needed to prune and summarize the jobs are populated.
+func (s Store) ListFinishedJobs(ctx context.Context, scheduleID int) ([]Job, error) {
+	var jobs []Job
+	err := s.SelectContext(ctx, &jobs, "SELECT id, schedule_id, status, created_at, updated_at, finished_at FROM jobs WHERE schedule_id = ? AND status NOT IN (?, ?) ORDER BY id DESC",
+		scheduleID,
+		JobStatusPending,
+		JobStatusRunning,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// PruneJobs deletes the jobs along with their events, after adding them to the daily summaries.
+func (s Store) PruneJobs(ctx context.Context, jobs []Job) error {
+	type summaryKey struct {
+		scheduleID int
+		day        string
+		status     string
+	}
+
+	type summary struct {
+		count         int
+		totalDuration float64
+	}
+
+	// Delete in batches to stay within SQLite's variable limit
+	const batchSize = 500
+
+	var batches [][]int
+	for start := 0; start < len(jobs); start += batchSize {
+		end := min(start+batchSize, len(jobs))
+
+		ids := make([]int, 0, end-start)
+		for _, job := range jobs[start:end] {
+			ids = append(ids, job.ID)
+		}
+		batches = append(batches, ids)
+	}
+
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Durations are measured as they are in the statistics, from the Machine starting
+	durations := make(map[int]float64)
+	for _, ids := range batches {
+		q, args, err := sqlx.In("SELECT id, "+jobDurationSQL+" AS duration FROM jobs WHERE id IN (?) AND finished_at IS NOT NULL", ids)
+		if err != nil {
+			return err
+		}
+
+		var rows []struct {
+			ID       int     `db:"id"`
+			Duration float64 `db:"duration"`
+		}
+		if err := tx.SelectContext(ctx, &rows, q, args...); err != nil {
+			return fmt.Errorf("error getting job durations: %w", err)
+		}
+
+		for _, row := range rows {
+			durations[row.ID] = row.Duration
+		}
+	}
+
+	summaries := make(map[summaryKey]*summary)
+	for _, job := range jobs {
+		key := summaryKey{job.ScheduleID, job.CreatedAt.UTC().Format("2006-01-02"), job.Status}
+		if summaries[key] == nil {
+			summaries[key] = &summary{}
+		}
+
+		summaries[key].count++
+		summaries[key].totalDuration += durations[job.ID]
+	}
+
+	for key, summary := range summaries {
+		_, err := tx.ExecContext(ctx, `INSERT INTO job_daily_summaries (schedule_id, day, status, count, total_duration) VALUES (?, ?, ?, ?, ?)
+			ON CONFLICT(schedule_id, day, status) DO UPDATE SET count = count + excluded.count, total_duration = total_duration + excluded.total_duration`,
+			key.scheduleID,
+			key.day,
+			key.status,
+			summary.count,
+			summary.totalDuration,
+		)
+		if err != nil {
+			return fmt.Errorf("error updating daily summary: %w", err)
+		}
+	}
+
+	for _, ids := range batches {
+		for _, query := range []string{
+			"DELETE FROM job_events WHERE job_id IN (?)",
+			"UPDATE jobs SET rerun_of = NULL WHERE rerun_of IN (?)",
+			"DELETE FROM jobs WHERE id IN (?)",
+		} {
+			q, args, err := sqlx.In(query, ids)
+			if err != nil {
+				return err
+			}
+
+			if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+				return fmt.Errorf("error pruning jobs: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// JobDailySummary holds the number of pruned jobs for a schedule, by day and status.
+type JobDailySummary struct {
+	ScheduleID    int     `json:"schedule_id" db:"schedule_id"`
+	Day           string  `json:"day" db:"day"`
+	Status        string  `json:"status" db:"status"`
+	Count         int     `json:"count" db:"count"`
+	TotalDuration float64 `json:"total_duration" db:"total_duration"`
+}
+
+func (s Store) ListJobDailySummaries(ctx context.Context, scheduleID int) ([]JobDailySummary, error) {
+	var summaries []JobDailySummary
+	if err := s.SelectContext(ctx, &summaries, "SELECT * FROM job_daily_summaries WHERE schedule_id = ? ORDER BY day, status", scheduleID); err != nil {
+		return nil, err
+	}
+	return summaries, nil
+}
+
+// jobStartedAtSQL is when the job's Machine started, falling back to when the job was dispatched to be launched,
+// or created, for jobs without a start event.
+const jobStartedAtSQL = "COALESCE((SELECT MIN(timestamp) FROM job_events WHERE job_events.job_id = jobs.id AND job_events.type = '" + JobEventStart + "'), dispatched_at, created_at)"
+
+// jobDurationSQL is the number of seconds between a job's Machine starting and the job finishing.
+const jobDurationSQL = "(julianday(finished_at) - julianday(" + jobStartedAtSQL + ")) * 86400"
+
+// failedJobStatuses are the statuses of jobs that ran but did not complete.
+var failedJobStatuses = []string{JobStatusFailed, JobStatusTimedOut, JobStatusLost}
+
+// JobOutcomes aggregates the jobs of a schedule that completed or failed within a window.
+type JobOutcomes struct {
+	Succeeded int `db:"succeeded"`
+	Failed    int `db:"failed"`
+	// Finished is the number of jobs with a finish time, and so a duration.
+	Finished    int     `db:"finished"`
+	MaxDuration float64 `db:"max_duration"`
+}
+
+func (s Store) CountJobOutcomes(ctx context.Context, scheduleID int, since time.Time) (*JobOutcomes, error) {
+	query, args, err := sqlx.In(`SELECT
+			COUNT(CASE WHEN status = ? THEN 1 END) AS succeeded,
+			COUNT(CASE WHEN status IN (?) THEN 1 END) AS failed,
+			COUNT(finished_at) AS finished,
+			COALESCE(MAX(`+jobDurationSQL+`), 0) AS max_duration
+		FROM jobs WHERE schedule_id = ? AND status IN (?) AND julianday(created_at) >= julianday(?)`,
+		JobStatusCompleted,
+		failedJobStatuses,
+		scheduleID,
+		append([]string{JobStatusCompleted}, failedJobStatuses...),
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var outcomes JobOutcomes
+	if err := s.GetContext(ctx, &outcomes, query, args...); err != nil {
+		return nil, fmt.Errorf("error counting jobs: %w", err)
+	}
+
+	return &outcomes, nil
+}
+
+// JobDurationPercentile returns the nearest-rank percentile, between 0 and 1, of the durations in seconds of the jobs
+// that completed or failed within a window. finished is the number of those jobs, as counted by CountJobOutcomes.
+func (s Store) JobDurationPercentile(ctx context.Context, scheduleID int, since time.Time, percentile float64, finished int) (float64, error) {
+	if finished == 0 {
+		return 0, nil
+	}
+
+	offset := max(int(math.Ceil(percentile*float64(finished)))-1, 0)
+
+	query, args, err := sqlx.In(`SELECT `+jobDurationSQL+` AS duration FROM jobs
+		WHERE schedule_id = ? AND status IN (?) AND finished_at IS NOT NULL AND julianday(created_at) >= julianday(?)
+		ORDER BY duration LIMIT 1 OFFSET ?`,
+		scheduleID,
+		append([]string{JobStatusCompleted}, failedJobStatuses...),
+		since,
+		offset,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var duration float64
+	if err := s.GetContext(ctx, &duration, query, args...); err != nil {
+		return 0, fmt.Errorf("error getting job duration: %w", err)
+	}
+
+	return duration, nil
+}
+
+// CountJobsLongerThan counts the jobs that completed or failed within a window and ran for longer than the duration.
+func (s Store) CountJobsLongerThan(ctx context.Context, scheduleID int, since time.Time, duration time.Duration) (int, error) {
+	query, args, err := sqlx.In(`SELECT COUNT(*) FROM jobs
+		WHERE schedule_id = ? AND status IN (?) AND julianday(created_at) >= julianday(?) AND `+jobDurationSQL+` > ?`,
+		scheduleID,
+		append([]string{JobStatusCompleted}, failedJobStatuses...),
+		since,
+		duration.Seconds(),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int
+	if err := s.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, fmt.Errorf("error counting jobs: %w", err)
+	}
+
+	return count, nil
+}
+
+// LastJobFinishedAt returns when the most recent job of a schedule with one of the statuses finished.
+func (s Store) LastJobFinishedAt(ctx context.Context, scheduleID int, statuses ...string) (sql.NullTime, error) {
+	var finishedAt sql.NullTime
+
+	query, args, err := sqlx.In("SELECT finished_at FROM jobs WHERE schedule_id = ? AND status IN (?) AND finished_at IS NOT NULL ORDER BY id DESC LIMIT 1", scheduleID, statuses)
+	if err != nil {
+		return finishedAt, err
+	}
+
+	if err := s.GetContext(ctx, &finishedAt, query, args...); err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return finishedAt, fmt.Errorf("error getting job: %w", err)
+	}
+
+	return finishedAt, nil
+}
+
+// JobFailureStreak counts the failed jobs of a schedule since its last completed job.
+func (s Store) JobFailureStreak(ctx context.Context, scheduleID int) (int, error) {
+	query, args, err := sqlx.In(`SELECT COUNT(*) FROM jobs WHERE schedule_id = ? AND status IN (?)
+		AND id > COALESCE((SELECT MAX(id) FROM jobs WHERE schedule_id = ? AND status = ?), 0)`,
+		scheduleID,
+		failedJobStatuses,
+		scheduleID,
+		JobStatusCompleted,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var streak int
+	if err := s.GetContext(ctx, &streak, query, args...); err != nil {
+		return 0, fmt.Errorf("error counting jobs: %w", err)
+	}
+
+	return streak, nil
+}
+
 func (s Store) CreateSchedule(ctx context.Context, sch Schedule) error {
 	cfgBytes, err := json.Marshal(sch.Config)
 	if err != nil {
 		return fmt.Errorf("error marshalling machine config: %w", err)
 	}
 
-	_, err = s.DB.ExecContext(ctx, "INSERT INTO schedules (name, app_name, schedule, command, command_timeout, region, enabled, config) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+	regionBytes, err := json.Marshal(sch.Regions)
+	if err != nil {
+		return fmt.Errorf("error marshalling regions: %w", err)
+	}
+
+	retentionBytes, err := json.Marshal(sch.Retention)
+	if err != nil {
+		return fmt.Errorf("error marshalling retention: %w", err)
+	}
+
+	sloBytes, err := json.Marshal(sch.SLO)
+	if err != nil {
+		return fmt.Errorf("error marshalling slo: %w", err)
+	}
+
+	blackoutBytes, err := json.Marshal(sch.Blackout)
+	if err != nil {
+		return fmt.Errorf("error marshalling blackout: %w", err)
+	}
+
+	_, err = s.DB.ExecContext(ctx, "INSERT INTO schedules (name, app_name, schedule, command, command_timeout, region, enabled, config, stop_signal, kill_timeout, regions, region_strategy, machine_mode, pin_image, pinned_image, retention, slo, jitter, priority, blackout) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
 		sch.Name,
 		sch.AppName,
 		sch.Schedule,
@@ -210,6 +838,18 @@ func (s Store) CreateSchedule(ctx context.Context, sch Schedule) error {
 		sch.Region,
 		sch.Enabled,
 		cfgBytes,
+		sch.StopSignal,
+		sch.KillTimeout,
+		regionBytes,
+		sch.RegionStrategy,
+		sch.MachineMode,
+		sch.PinImage,
+		sch.PinnedImage,
+		retentionBytes,
+		sloBytes,
+		sch.Jitter,
+		sch.Priority,
+		blackoutBytes,
 	)
 
 	return err
@@ -221,7 +861,27 @@ func (s Store) UpdateSchedule(ctx context.Context, sch Schedule) error {
 		return fmt.Errorf("error marshalling machine config: %w", err)
 	}
 
-	_, err = s.DB.ExecContext(ctx, "UPDATE schedules SET app_name = ?, schedule = ?, command = ?, command_timeout = ?, region = ?, enabled = ?, config = ? WHERE name = ?",
+	regionBytes, err := json.Marshal(sch.Regions)
+	if err != nil {
+		return fmt.Errorf("error marshalling regions: %w", err)
+	}
+
+	retentionBytes, err := json.Marshal(sch.Retention)
+	if err != nil {
+		return fmt.Errorf("error marshalling retention: %w", err)
+	}
+
+	sloBytes, err := json.Marshal(sch.SLO)
+	if err != nil {
+		return fmt.Errorf("error marshalling slo: %w", err)
+	}
+
+	blackoutBytes, err := json.Marshal(sch.Blackout)
+	if err != nil {
+		return fmt.Errorf("error marshalling blackout: %w", err)
+	}
+
+	_, err = s.DB.ExecContext(ctx, "UPDATE schedules SET app_name = ?, schedule = ?, command = ?, command_timeout = ?, region = ?, enabled = ?, config = ?, stop_signal = ?, kill_timeout = ?, regions = ?, region_strategy = ?, machine_mode = ?, pin_image = ?, pinned_image = ?, retention = ?, slo = ?, jitter = ?, priority = ?, blackout = ? WHERE name = ?",
 		sch.AppName,
 		sch.Schedule,
 		sch.Command,
@@ -229,6 +889,18 @@ func (s Store) UpdateSchedule(ctx context.Context, sch Schedule) error {
 		sch.Region,
 		sch.Enabled,
 		cfgBytes,
+		sch.StopSignal,
+		sch.KillTimeout,
+		regionBytes,
+		sch.RegionStrategy,
+		sch.MachineMode,
+		sch.PinImage,
+		sch.PinnedImage,
+		retentionBytes,
+		sloBytes,
+		sch.Jitter,
+		sch.Priority,
+		blackoutBytes,
 		sch.Name,
 	)
 
@@ -242,14 +914,232 @@ func (s Store) DeleteSchedule(ctx context.Context, id string) error {
 	}
 
 	// Delete all jobs associated with the schedule
+	_, err = s.ExecContext(ctx, "DELETE FROM job_events WHERE job_id IN (SELECT id FROM jobs WHERE schedule_id = ?)", id)
+	if err != nil {
+		return fmt.Errorf("error deleting job events: %w", err)
+	}
+
 	_, err = s.ExecContext(ctx, "DELETE FROM jobs WHERE schedule_id = ?", id)
-	return err
-}
+	if err != nil {
+		return fmt.Errorf("error deleting jobs: %w", err)
+	}
 
-func (s Store) CreateJob(ctx context.Context, scheduleID int) (*Job, error) {
-	result, err := s.DB.ExecContext(ctx, "INSERT INTO jobs (schedule_id, status, created_at, updated_at) VALUES ($1, $2, $3, $4)",
-		scheduleID,
-		JobStatusPending,
+	_, err = s.ExecContext(ctx, "DELETE FROM schedule_machines WHERE schedule_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting schedule machine: %w", err)
+	}
+
+	_, err = s.ExecContext(ctx, "DELETE FROM job_daily_summaries WHERE schedule_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting job summaries: %w", err)
+	}
+
+	_, err = s.ExecContext(ctx, "DELETE FROM schedule_alerts WHERE schedule_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting schedule alerts: %w", err)
+	}
+
+	_, err = s.ExecContext(ctx, "DELETE FROM watchdog_cursors WHERE schedule_id = ?", id)
+	return err
+}
+
+func (s Store) FindScheduleMachine(ctx context.Context, scheduleID int) (*ScheduleMachine, error) {
+	var machine ScheduleMachine
+	if err := s.GetContext(ctx, &machine, "SELECT * FROM schedule_machines WHERE schedule_id = ?", scheduleID); err != nil {
+		return nil, err
+	}
+	return &machine, nil
+}
+
+// SetScheduleEnabled enables or disables the schedule, until schedules are next synced from the schedules file.
+func (s Store) SetScheduleEnabled(ctx context.Context, id int, enabled bool) error {
+	_, err := s.ExecContext(ctx, "UPDATE schedules SET enabled = ? WHERE id = ?", enabled, id)
+	return err
+}
+
+func (s Store) SetScheduleMachine(ctx context.Context, scheduleID int, machineID, configHash string) error {
+	_, err := s.ExecContext(ctx, `INSERT INTO schedule_machines (schedule_id, machine_id, config_hash, created_at, updated_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(schedule_id) DO UPDATE SET machine_id = excluded.machine_id, config_hash = excluded.config_hash, updated_at = excluded.updated_at`,
+		scheduleID,
+		machineID,
+		configHash,
+		time.Now(),
+		time.Now(),
+	)
+	return err
+}
+
+func (s Store) DeleteScheduleMachine(ctx context.Context, scheduleID int) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM schedule_machines WHERE schedule_id = ?", scheduleID)
+	return err
+}
+
+// ScheduleAlert is raised by the watchdog when a schedule does not run as expected.
+// The alert stays open until a later run of the schedule succeeds, or the condition otherwise clears.
+type ScheduleAlert struct {
+	ID         int    `json:"id" db:"id"`
+	ScheduleID int    `json:"schedule_id" db:"schedule_id"`
+	Kind       string `json:"kind" db:"kind"`
+	Message    string `json:"message" db:"message"`
+	// ExpectedAt is the expected run of the schedule that was missed.
+	ExpectedAt sql.NullTime `json:"expected_at" db:"expected_at"`
+	CreatedAt  time.Time    `json:"created_at" db:"created_at"`
+	ResolvedAt sql.NullTime `json:"resolved_at" db:"resolved_at"`
+}
+
+func (s Store) FindOpenScheduleAlert(ctx context.Context, scheduleID int, kind string) (*ScheduleAlert, error) {
+	var alert ScheduleAlert
+	if err := s.GetContext(ctx, &alert, "SELECT * FROM schedule_alerts WHERE schedule_id = ? AND kind = ? AND resolved_at IS NULL ORDER BY id DESC LIMIT 1", scheduleID, kind); err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+func (s Store) ListOpenScheduleAlerts(ctx context.Context) ([]ScheduleAlert, error) {
+	var alerts []ScheduleAlert
+	if err := s.SelectContext(ctx, &alerts, "SELECT * FROM schedule_alerts WHERE resolved_at IS NULL ORDER BY id"); err != nil {
+		return nil, fmt.Errorf("error getting schedule alerts: %w", err)
+	}
+	return alerts, nil
+}
+
+// CountScheduleAlerts returns the number of alerts ever raised, by kind.
+// The counts are kept apart from the alerts, so they don't go down when a schedule and its alerts are deleted.
+func (s Store) CountScheduleAlerts(ctx context.Context) (map[string]int, error) {
+	var rows []struct {
+		Kind  string `db:"kind"`
+		Count int    `db:"count"`
+	}
+	if err := s.SelectContext(ctx, &rows, "SELECT kind, count FROM schedule_alert_counts"); err != nil {
+		return nil, fmt.Errorf("error counting schedule alerts: %w", err)
+	}
+
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.Kind] = row.Count
+	}
+
+	return counts, nil
+}
+
+func (s Store) CreateScheduleAlert(ctx context.Context, alert ScheduleAlert) (*ScheduleAlert, error) {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO schedule_alerts (schedule_id, kind, message, expected_at, created_at) VALUES (?, ?, ?, ?, ?)",
+		alert.ScheduleID,
+		alert.Kind,
+		alert.Message,
+		alert.ExpectedAt,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating schedule alert: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO schedule_alert_counts (kind, count) VALUES (?, 1) ON CONFLICT(kind) DO UPDATE SET count = count + 1", alert.Kind); err != nil {
+		return nil, fmt.Errorf("error counting schedule alert: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	var created ScheduleAlert
+	if err := s.GetContext(ctx, &created, "SELECT * FROM schedule_alerts WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+func (s Store) ResolveScheduleAlert(ctx context.Context, id int) error {
+	_, err := s.ExecContext(ctx, "UPDATE schedule_alerts SET resolved_at = ? WHERE id = ?", time.Now(), id)
+	return err
+}
+
+// FindWatchdogCursor returns the last expected run of the schedule the watchdog checked for the kind of cursor.
+func (s Store) FindWatchdogCursor(ctx context.Context, scheduleID int, kind string) (time.Time, error) {
+	var checkedAt time.Time
+	if err := s.GetContext(ctx, &checkedAt, "SELECT checked_at FROM watchdog_cursors WHERE schedule_id = ? AND kind = ?", scheduleID, kind); err != nil {
+		return time.Time{}, err
+	}
+	return checkedAt, nil
+}
+
+func (s Store) SetWatchdogCursor(ctx context.Context, scheduleID int, kind string, checkedAt time.Time) error {
+	_, err := s.ExecContext(ctx, `INSERT INTO watchdog_cursors (schedule_id, kind, checked_at) VALUES (?, ?, ?)
+		ON CONFLICT(schedule_id, kind) DO UPDATE SET checked_at = excluded.checked_at`,
+		scheduleID,
+		kind,
+		checkedAt,
+	)
+	return err
+}
+
+func (s Store) DeleteWatchdogCursors(ctx context.Context, scheduleID int) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM watchdog_cursors WHERE schedule_id = ?", scheduleID)
+	return err
+}
+
+// CountJobsCreatedBetween counts the scheduled jobs of a schedule created within the period, optionally only those with one of the statuses.
+// Jobs triggered by hand and reruns are not counted, as they are not runs of the schedule.
+func (s Store) CountJobsCreatedBetween(ctx context.Context, scheduleID int, from, to time.Time, statuses ...string) (int, error) {
+	query := "SELECT COUNT(*) FROM jobs WHERE schedule_id = ? AND scheduled = 1 AND julianday(created_at) BETWEEN julianday(?) AND julianday(?)"
+	args := []any{scheduleID, from, to}
+
+	if len(statuses) > 0 {
+		var err error
+		query, args, err = sqlx.In(query+" AND status IN (?)", append(args, statuses)...)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var count int
+	if err := s.GetContext(ctx, &count, query, args...); err != nil {
+		return 0, fmt.Errorf("error counting jobs: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListJobsCreatedBetween returns the scheduled jobs of a schedule created within the period, oldest first.
+// As with CountJobsCreatedBetween, jobs triggered by hand and reruns are left out.
+func (s Store) ListJobsCreatedBetween(ctx context.Context, scheduleID int, from, to time.Time) ([]Job, error) {
+	var jobs []Job
+	err := s.SelectContext(ctx, &jobs, "SELECT * FROM jobs WHERE schedule_id = ? AND scheduled = 1 AND julianday(created_at) BETWEEN julianday(?) AND julianday(?) ORDER BY id",
+		scheduleID,
+		from,
+		to,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error listing jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// CreateJob creates a pending job, optionally recording the job it's a rerun of.
+func (s Store) CreateJob(ctx context.Context, scheduleID int, rerunOf sql.NullInt64) (*Job, error) {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, "INSERT INTO jobs (schedule_id, status, rerun_of, created_at, updated_at) VALUES ($1, $2, $3, $4, $5)",
+		scheduleID,
+		JobStatusPending,
+		rerunOf,
 		time.Now(),
 		time.Now(),
 	)
@@ -264,6 +1154,14 @@ func (s Store) CreateJob(ctx context.Context, scheduleID int) (*Job, error) {
 		return nil, fmt.Errorf("error getting last insert ID: %w", err)
 	}
 
+	if err := recordJobStateEvent(ctx, tx, int(id)); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	job, err := s.FindJob(ctx, fmt.Sprint(id))
 	if err != nil {
 		return nil, fmt.Errorf("error finding job: %w", err)
@@ -273,7 +1171,7 @@ func (s Store) CreateJob(ctx context.Context, scheduleID int) (*Job, error) {
 }
 
 func (s Store) UpdateJobStatus(ctx context.Context, id int, status string) error {
-	_, err := s.ExecContext(ctx, "UPDATE jobs SET status = ?, updated_at = ? WHERE id = ?",
+	_, err := s.updateJobState(ctx, id, "UPDATE jobs SET status = ?, updated_at = ? WHERE id = ?",
 		status,
 		time.Now(),
 		id,
@@ -281,9 +1179,90 @@ func (s Store) UpdateJobStatus(ctx context.Context, id int, status string) error
 	return err
 }
 
-func (s Store) UpdateJobMachine(ctx context.Context, id int, machineID string) error {
-	_, err := s.ExecContext(ctx, "UPDATE jobs SET machine_id = ?, updated_at = ? WHERE id = ?",
-		machineID,
+// StartJob marks a pending job as running, returning false if the job is no longer pending,
+// e.g. because it was cancelled while its Machine was being launched.
+func (s Store) StartJob(ctx context.Context, id int) (bool, error) {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, "UPDATE jobs SET status = ?, updated_at = ? WHERE id = ? AND status = ?",
+		JobStatusRunning,
+		time.Now(),
+		id,
+		JobStatusPending,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	if err := recordJobStateEvent(ctx, tx, id); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (s Store) UpdateJobMachine(ctx context.Context, id int, machine *fly.Machine, region string) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET machine_id = ?, region = ?, image_digest = ?, updated_at = ? WHERE id = ?",
+		machine.ID,
+		region,
+		sql.NullString{String: machine.ImageRef.Digest, Valid: machine.ImageRef.Digest != ""},
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+func (s Store) SetJobConfigSnapshot(ctx context.Context, id int, config fly.MachineConfig) error {
+	cfgBytes, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("error marshalling machine config: %w", err)
+	}
+
+	_, err = s.ExecContext(ctx, "UPDATE jobs SET config_snapshot = ?, updated_at = ? WHERE id = ?",
+		string(cfgBytes),
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+func (s Store) SetJobMachineSnapshot(ctx context.Context, id int, machine *fly.Machine) error {
+	eventBytes, err := json.Marshal(machine.Events)
+	if err != nil {
+		return fmt.Errorf("error marshalling machine events: %w", err)
+	}
+
+	_, err = s.ExecContext(ctx, "UPDATE jobs SET machine_state = ?, machine_events = ? WHERE id = ?",
+		machine.State,
+		string(eventBytes),
+		id,
+	)
+	return err
+}
+
+func (s Store) SetJobLaunchAttempts(ctx context.Context, id int, attempts LaunchAttempts) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET launch_attempts = ?, updated_at = ? WHERE id = ?",
+		attempts,
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// SetJobJitterDelay records the jitter delay of the job, which isn't dispatched from the launch queue until it has passed.
+func (s Store) SetJobJitterDelay(ctx context.Context, id int, delay time.Duration) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET jitter_delay = ?, not_before = ?, updated_at = ? WHERE id = ?",
+		int(delay.Seconds()),
+		time.Now().Add(delay),
 		time.Now(),
 		id,
 	)
@@ -302,20 +1281,42 @@ func (s Store) SetJobResult(ctx context.Context, id int, status string, exitCode
 	return err
 }
 
-func (s Store) FailJob(ctx context.Context, id int, exitCode int, stderr string) error {
-	_, err := s.ExecContext(ctx, "UPDATE jobs SET status = ?, exit_code = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+func (s Store) FailJob(ctx context.Context, id int, exitCode int, reason, stderr string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, exit_code = ?, failure_reason = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ?",
 		JobStatusFailed,
 		exitCode,
+		reason,
+		stderr,
+		time.Now(),
+		time.Now(),
+		id,
+	)
+}
+
+func (s Store) TimeoutJob(ctx context.Context, id int, stderr string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, failure_reason = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+		JobStatusTimedOut,
+		FailureReasonCommandTimeout,
 		stderr,
 		time.Now(),
 		time.Now(),
 		id,
 	)
-	return err
 }
 
-func (s Store) CompleteJob(ctx context.Context, id int, exitCode int, stdout string) error {
-	_, err := s.ExecContext(ctx, "UPDATE jobs SET status = ?, exit_code = ?, stdout = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+func (s Store) LoseJob(ctx context.Context, id int, reason, stderr string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, failure_reason = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+		JobStatusLost,
+		reason,
+		stderr,
+		time.Now(),
+		time.Now(),
+		id,
+	)
+}
+
+func (s Store) CompleteJob(ctx context.Context, id int, exitCode int, stdout string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, exit_code = ?, stdout = ?, updated_at = ?, finished_at = ? WHERE id = ?",
 		JobStatusCompleted,
 		exitCode,
 		stdout,
@@ -323,15 +1324,245 @@ func (s Store) CompleteJob(ctx context.Context, id int, exitCode int, stdout str
 		time.Now(),
 		id,
 	)
+}
+
+func (s Store) RequestJobStop(ctx context.Context, id int) error {
+	_, err := s.ExecContext(ctx, "UPDATE jobs SET stop_requested_at = ?, updated_at = ? WHERE id = ?",
+		time.Now(),
+		time.Now(),
+		id,
+	)
+	return err
+}
+
+// SkipJob marks a pending job as skipped, recording the reason along with a message explaining it.
+// Returns false if the job is no longer pending or running.
+func (s Store) SkipJob(ctx context.Context, id int, reason, message string) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, failure_reason = ?, stderr = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+		JobStatusSkipped,
+		reason,
+		message,
+		time.Now(),
+		time.Now(),
+		id,
+	)
+}
+
+// CancelJob marks a pending or running job as cancelled, returning false if the job had already finished.
+func (s Store) CancelJob(ctx context.Context, id int) (bool, error) {
+	return s.finishJob(ctx, id, "UPDATE jobs SET status = ?, updated_at = ?, finished_at = ? WHERE id = ?",
+		JobStatusCancelled,
+		time.Now(),
+		time.Now(),
+		id,
+	)
+}
+
+// finishJob runs an update moving a pending or running job to a terminal status, returning false if the job had
+// already finished. Jobs finishing as they're cancelled are left as they finished, and cancelled jobs aren't
+// rewritten by the monitor.
+func (s Store) finishJob(ctx context.Context, id int, query string, args ...any) (bool, error) {
+	return s.updateJobState(ctx, id, query+" AND status IN (?, ?)", append(args, JobStatusPending, JobStatusRunning)...)
+}
+
+// updateJobState runs an update of the job's status, recording the change of state as an event in the same transaction.
+// Returns false, without recording an event, if the update matched no job.
+func (s Store) updateJobState(ctx context.Context, id int, query string, args ...any) (bool, error) {
+	tx, err := s.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	result, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil || n == 0 {
+		return false, err
+	}
+
+	if err := recordJobStateEvent(ctx, tx, id); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// recordJobStateEvent records an event for the job's current status.
+func recordJobStateEvent(ctx context.Context, tx *sqlx.Tx, id int) error {
+	var job Job
+	if err := tx.GetContext(ctx, &job, "SELECT * FROM jobs WHERE id = ?", id); err != nil {
+		return fmt.Errorf("error getting job: %w", err)
+	}
+
+	_, err := tx.ExecContext(ctx, "INSERT INTO events (type, schedule_id, job_id, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		jobEventType(job.Status),
+		job.ScheduleID,
+		job.ID,
+		job.Status,
+		time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("error recording event: %w", err)
+	}
+
+	return nil
+}
+
+// RecordEvent records an event that isn't tied to a job's state, such as schedules being synced.
+func (s Store) RecordEvent(ctx context.Context, event Event) error {
+	_, err := s.ExecContext(ctx, "INSERT INTO events (type, schedule_id, job_id, status, created_at) VALUES (?, ?, ?, ?, ?)",
+		event.Type,
+		event.ScheduleID,
+		event.JobID,
+		event.Status,
+		time.Now(),
+	)
+	return err
+}
+
+// ListEventsAfter returns up to limit events recorded after the event with the ID, oldest first.
+func (s Store) ListEventsAfter(ctx context.Context, id int, limit int) ([]Event, error) {
+	var events []Event
+	if err := s.DB.SelectContext(ctx, &events, "SELECT * FROM events WHERE id > ? ORDER BY id LIMIT ?", id, limit); err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LastEventID returns the ID of the most recent event, or 0 if none have been recorded.
+func (s Store) LastEventID(ctx context.Context) (int, error) {
+	var id int
+	if err := s.DB.GetContext(ctx, &id, "SELECT COALESCE(MAX(id), 0) FROM events"); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// PruneEvents deletes the events recorded before the time.
+func (s Store) PruneEvents(ctx context.Context, before time.Time) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM events WHERE julianday(created_at) < julianday(?)", before)
 	return err
 }
 
+func (s Store) CreatePause(ctx context.Context, pause Pause) (*Pause, error) {
+	result, err := s.ExecContext(ctx, "INSERT INTO pauses (schedule_name, app_name, reason, until, created_at) VALUES (?, ?, ?, ?, ?)",
+		pause.ScheduleName,
+		pause.AppName,
+		pause.Reason,
+		pause.Until,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating pause: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	var created Pause
+	if err := s.GetContext(ctx, &created, "SELECT * FROM pauses WHERE id = ?", id); err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// ListPauses returns the pauses in effect at the time, oldest first.
+func (s Store) ListPauses(ctx context.Context, at time.Time) ([]Pause, error) {
+	pauses := []Pause{}
+	if err := s.SelectContext(ctx, &pauses, "SELECT * FROM pauses WHERE until IS NULL OR julianday(until) > julianday(?) ORDER BY id", at); err != nil {
+		return nil, fmt.Errorf("error listing pauses: %w", err)
+	}
+
+	return pauses, nil
+}
+
+// DeletePauses deletes the pauses of exactly the schedule and app, where empty names pause everything,
+// returning the number of pauses deleted.
+func (s Store) DeletePauses(ctx context.Context, scheduleName, appName string) (int, error) {
+	result, err := s.ExecContext(ctx, "DELETE FROM pauses WHERE schedule_name = ? AND app_name = ?", scheduleName, appName)
+	if err != nil {
+		return 0, fmt.Errorf("error deleting pauses: %w", err)
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// PrunePauses deletes the pauses that expired before the time.
+func (s Store) PrunePauses(ctx context.Context, before time.Time) error {
+	_, err := s.ExecContext(ctx, "DELETE FROM pauses WHERE until IS NOT NULL AND julianday(until) < julianday(?)", before)
+	return err
+}
+
+func (s Store) RecordJobEvents(ctx context.Context, events []JobEvent) error {
+	for _, event := range events {
+		// Machine events are reported on every poll, so ignore the ones we have already recorded.
+		_, err := s.ExecContext(ctx, "INSERT OR IGNORE INTO job_events (job_id, type, status, source, timestamp, created_at) VALUES (?, ?, ?, ?, ?, ?)",
+			event.JobID,
+			event.Type,
+			event.Status,
+			event.Source,
+			event.Timestamp.UTC(),
+			time.Now(),
+		)
+		if err != nil {
+			return fmt.Errorf("error recording job event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s Store) ListJobEvents(ctx context.Context, jobID int) ([]JobEvent, error) {
+	var events []JobEvent
+	if err := s.DB.SelectContext(ctx, &events, "SELECT * FROM job_events WHERE job_id = ? ORDER BY timestamp ASC, id ASC", jobID); err != nil {
+		return nil, fmt.Errorf("error getting job events: %w", err)
+	}
+
+	return events, nil
+}
+
 func convertToStandardSchedule(raw RawSchedule) (*Schedule, error) {
 	var cfg fly.MachineConfig
 	if err := json.Unmarshal([]byte(raw.Config), &cfg); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
 
+	var regions []string
+	if raw.Regions != "" {
+		if err := json.Unmarshal([]byte(raw.Regions), &regions); err != nil {
+			return nil, fmt.Errorf("error unmarshaling regions: %w", err)
+		}
+	}
+
+	var retention Retention
+	if raw.Retention != "" {
+		if err := json.Unmarshal([]byte(raw.Retention), &retention); err != nil {
+			return nil, fmt.Errorf("error unmarshaling retention: %w", err)
+		}
+	}
+
+	var slo SLO
+	if raw.SLO != "" {
+		if err := json.Unmarshal([]byte(raw.SLO), &slo); err != nil {
+			return nil, fmt.Errorf("error unmarshaling slo: %w", err)
+		}
+	}
+
+	var blackout []BlackoutWindow
+	if raw.Blackout != "" {
+		if err := json.Unmarshal([]byte(raw.Blackout), &blackout); err != nil {
+			return nil, fmt.Errorf("error unmarshaling blackout: %w", err)
+		}
+	}
+
 	return &Schedule{
 		ID:             raw.ID,
 		Name:           raw.Name,
@@ -342,6 +1573,18 @@ func convertToStandardSchedule(raw RawSchedule) (*Schedule, error) {
 		Region:         raw.Region,
 		Enabled:        raw.Enabled,
 		Config:         cfg,
+		StopSignal:     raw.StopSignal,
+		KillTimeout:    raw.KillTimeout,
+		Regions:        regions,
+		RegionStrategy: raw.RegionStrategy,
+		MachineMode:    raw.MachineMode,
+		PinImage:       raw.PinImage,
+		PinnedImage:    raw.PinnedImage,
+		Retention:      retention,
+		SLO:            slo,
+		Jitter:         raw.Jitter,
+		Priority:       raw.Priority,
+		Blackout:       blackout,
 	}, nil
 }
 
@@ -0,0 +1,77 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestListJobsFiltered(t *testing.T) {
+	ctx := context.TODO()
+
+	store, _, schedule := setupJobTest(t)
+
+	// Jobs created an hour apart, with durations of 30s, 10s, 20s and a running job. Their times are stored
+	// with different offsets, as they are across a DST or time zone change.
+	zones := []*time.Location{time.FixedZone("UTC-10", -10*60*60), time.FixedZone("UTC+5", 5*60*60)}
+	durations := []time.Duration{30 * time.Second, 10 * time.Second, 20 * time.Second, 0}
+	var ids []int
+	for i, duration := range durations {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, job.ID)
+
+		createdAt := time.Now().Add(-time.Duration(len(durations)-i) * time.Hour).In(zones[i%len(zones)])
+		if duration == 0 {
+			if _, err := store.ExecContext(ctx, "UPDATE jobs SET status = ?, created_at = ? WHERE id = ?", JobStatusRunning, createdAt, job.ID); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET status = ?, created_at = ?, finished_at = ? WHERE id = ?", JobStatusCompleted, createdAt, createdAt.Add(duration), job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter JobFilter
+		ids    []int
+	}{
+		{"newest first", JobFilter{ScheduleID: schedule.ID}, []int{ids[3], ids[2], ids[1], ids[0]}},
+		{"by status", JobFilter{Statuses: []string{JobStatusRunning}}, []int{ids[3]}},
+		{"since", JobFilter{Since: time.Now().Add(-150 * time.Minute)}, []int{ids[3], ids[2]}},
+		{"until", JobFilter{Until: time.Now().Add(-150 * time.Minute)}, []int{ids[1], ids[0]}},
+		{"since in another time zone", JobFilter{Since: time.Now().Add(-150 * time.Minute).In(time.FixedZone("UTC+5", 5*60*60))}, []int{ids[3], ids[2]}},
+		{"paged", JobFilter{Limit: 2, Offset: 1}, []int{ids[2], ids[1]}},
+		{"longest first", JobFilter{Sort: JobSortDuration}, []int{ids[0], ids[2], ids[1], ids[3]}},
+		{"shortest first", JobFilter{Sort: JobSortDuration, Ascending: true, Limit: 1}, []int{ids[1]}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := store.ListJobsFiltered(ctx, tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []int
+			for _, job := range jobs {
+				got = append(got, job.ID)
+			}
+
+			if len(got) != len(tt.ids) {
+				t.Fatalf("expected jobs %v, got %v", tt.ids, got)
+			}
+			for i := range got {
+				if got[i] != tt.ids[i] {
+					t.Fatalf("expected jobs %v, got %v", tt.ids, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	watchdogFrequency    = 30 * time.Second
+	defaultWatchdogGrace = 5 * time.Minute
+
+	// maxWatchdogRuns bounds the number of expected runs checked for a schedule at once, e.g. after the monitor was stopped.
+	maxWatchdogRuns = 100
+)
+
+// Alert kinds raised by the watchdog.
+const (
+	AlertMissedRun        = "missed_run"
+	AlertMissedCompletion = "missed_completion"
+	AlertScheduleDisabled = "schedule_disabled"
+)
+
+// Watchdog cursors, which hold the last expected run of a schedule checked for job creation and completion.
+const (
+	watchdogCursorCreated   = "created"
+	watchdogCursorCompleted = "completed"
+)
+
+// Watchdog raises alerts for schedules that stop running. For each expected run of a schedule, it checks that a job
+// was created within the grace period, and that a job completed within the grace period plus the schedule's timeouts
+// of leaving the launch queue.
+type Watchdog struct {
+	store     *Store
+	log       *logrus.Logger
+	grace     time.Duration
+	notifiers []Notifier
+
+	// invalid holds the schedules whose expression could not be parsed, so they are only warned about once.
+	invalid map[int]string
+}
+
+func NewWatchdog(store *Store, log *logrus.Logger, grace time.Duration, notifiers ...Notifier) *Watchdog {
+	return &Watchdog{
+		store:     store,
+		log:       log,
+		grace:     grace,
+		notifiers: notifiers,
+		invalid:   make(map[int]string),
+	}
+}
+
+// WatchdogFromEnv returns a watchdog with the grace period set with WATCHDOG_GRACE, which defaults to 5m.
+// Alerts are logged, and posted to WATCHDOG_WEBHOOK_URL if it's set. Returns nil if WATCHDOG_GRACE is 0.
+func WatchdogFromEnv(store *Store, log *logrus.Logger) (*Watchdog, error) {
+	grace, err := ParseDuration(getEnvOrDefault("WATCHDOG_GRACE", defaultWatchdogGrace.String()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid WATCHDOG_GRACE: %w", err)
+	}
+
+	if grace == 0 {
+		return nil, nil
+	}
+
+	notifiers := []Notifier{NewLogNotifier(log)}
+	if url := getEnvOrDefault("WATCHDOG_WEBHOOK_URL", ""); url != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(url))
+	}
+
+	return NewWatchdog(store, log, grace, notifiers...), nil
+}
+
+// Check evaluates the expected runs of every schedule up to now, less the grace period.
+// Expected runs are only checked from when a schedule is first seen by the watchdog.
+// The last expected run checked is stored, so runs missed while the monitor was down are still checked.
+func (w *Watchdog) Check(ctx context.Context, now time.Time) error {
+	schedules, err := w.store.ListSchedules(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+
+	for _, schedule := range schedules {
+		if !schedule.Enabled {
+			// Checking resumes from when the schedule is enabled again
+			if err := w.store.DeleteWatchdogCursors(ctx, schedule.ID); err != nil {
+				return fmt.Errorf("failed to reset watchdog cursors: %w", err)
+			}
+
+			if err := w.raise(ctx, schedule, AlertScheduleDisabled, time.Time{}, fmt.Sprintf("schedule %s is disabled and will not run", schedule.Name)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := w.resolve(ctx, schedule, AlertScheduleDisabled); err != nil {
+			return err
+		}
+
+		expr, err := ParseCronExpression(schedule.Schedule)
+		if err != nil {
+			if w.invalid[schedule.ID] != schedule.Schedule {
+				w.log.WithError(err).Warnf("Watchdog can not check schedule %s", schedule.Name)
+				w.invalid[schedule.ID] = schedule.Schedule
+			}
+			continue
+		}
+		delete(w.invalid, schedule.ID)
+
+		if err := w.checkCreated(ctx, schedule, expr, now); err != nil {
+			return err
+		}
+
+		if err := w.checkCompleted(ctx, schedule, expr, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkCreated raises an alert for each expected run without a job created within the grace period.
+func (w *Watchdog) checkCreated(ctx context.Context, schedule Schedule, expr *CronExpression, now time.Time) error {
+	return w.checkRuns(ctx, schedule, expr, watchdogCursorCreated, now.Add(-w.grace), func(expected time.Time) (bool, error) {
+		count, err := w.store.CountJobsCreatedBetween(ctx, schedule.ID, expected, runDeadline(expr, expected, w.grace))
+		if err != nil {
+			return false, err
+		}
+
+		if count > 0 {
+			return true, w.resolve(ctx, schedule, AlertMissedRun)
+		}
+
+		return true, w.raise(ctx, schedule, AlertMissedRun, expected, fmt.Sprintf("no job was created for the run of schedule %s expected at %s", schedule.Name, expected.UTC().Format(time.RFC3339)))
+	})
+}
+
+// checkCompleted raises an alert for each expected run without a completed job, once the job would have timed out.
+// The timeouts are counted from the job leaving the launch queue, so jobs held up by the concurrency limits or their
+// jitter delay are waited for, until the next run is due to have completed as well.
+func (w *Watchdog) checkCompleted(ctx context.Context, schedule Schedule, expr *CronExpression, now time.Time) error {
+	timeout := time.Duration(schedule.CommandTimeout+schedule.KillTimeout) * time.Second
+	cutoff := now.Add(-w.grace - timeout)
+
+	return w.checkRuns(ctx, schedule, expr, watchdogCursorCompleted, cutoff, func(expected time.Time) (bool, error) {
+		jobs, err := w.store.ListJobsCreatedBetween(ctx, schedule.ID, expected, runDeadline(expr, expected, w.grace))
+		if err != nil {
+			return false, err
+		}
+
+		// Skipped runs were not expected to complete
+		for _, job := range jobs {
+			if job.Status == JobStatusCompleted || job.Status == JobStatusSkipped {
+				return true, w.resolve(ctx, schedule, AlertMissedCompletion)
+			}
+		}
+
+		for _, job := range jobs {
+			if job.Status != JobStatusPending && job.Status != JobStatusRunning {
+				continue
+			}
+
+			// Jobs within their timeouts of leaving the queue may yet complete
+			if job.DispatchedAt.Valid && now.Before(job.DispatchedAt.Time.Add(timeout+w.grace)) {
+				return false, nil
+			}
+
+			if next := expr.Next(expected); !job.DispatchedAt.Valid && (next.IsZero() || next.After(cutoff)) {
+				return false, nil
+			}
+		}
+
+		return true, w.raise(ctx, schedule, AlertMissedCompletion, expected, fmt.Sprintf("no job completed for the run of schedule %s expected at %s", schedule.Name, expected.UTC().Format(time.RFC3339)))
+	})
+}
+
+// checkRuns calls check for each expected run after the schedule's cursor, up until the cutoff, advancing the cursor as it goes.
+// check returns false if the run can't be decided yet, in which case it's checked again, along with the runs after it, next time.
+func (w *Watchdog) checkRuns(ctx context.Context, schedule Schedule, expr *CronExpression, kind string, cutoff time.Time, check func(expected time.Time) (bool, error)) error {
+	cursor, err := w.store.FindWatchdogCursor(ctx, schedule.ID, kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return w.setCursor(ctx, schedule, kind, cutoff)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find watchdog cursor: %w", err)
+	}
+
+	for i := 0; ; i++ {
+		expected := expr.Next(cursor)
+		if expected.IsZero() || expected.After(cutoff) {
+			return nil
+		}
+
+		// Skip ahead to the most recent runs rather than checking an unbounded backlog
+		if i == maxWatchdogRuns {
+			w.log.Warnf("Watchdog skipped expected runs of schedule %s up to %s", schedule.Name, cutoff.UTC().Format(time.RFC3339))
+			return w.setCursor(ctx, schedule, kind, cutoff)
+		}
+
+		checked, err := check(expected)
+		if err != nil {
+			return err
+		}
+		if !checked {
+			return nil
+		}
+
+		cursor = expected
+		if err := w.setCursor(ctx, schedule, kind, cursor); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *Watchdog) setCursor(ctx context.Context, schedule Schedule, kind string, cursor time.Time) error {
+	if err := w.store.SetWatchdogCursor(ctx, schedule.ID, kind, cursor); err != nil {
+		return fmt.Errorf("failed to store watchdog cursor of schedule %s: %w", schedule.Name, err)
+	}
+	return nil
+}
+
+// runDeadline is the end of the period a job for the expected run may be created in,
+// which is the grace period, or up until the next expected run if that comes first.
+func runDeadline(expr *CronExpression, expected time.Time, grace time.Duration) time.Time {
+	deadline := expected.Add(grace)
+	if next := expr.Next(expected); !next.IsZero() && next.Before(deadline) {
+		return next
+	}
+	return deadline
+}
+
+// raise opens an alert of the kind for the schedule, unless one is already open.
+func (w *Watchdog) raise(ctx context.Context, schedule Schedule, kind string, expected time.Time, message string) error {
+	if _, err := w.store.FindOpenScheduleAlert(ctx, schedule.ID, kind); err == nil {
+		return nil
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to find schedule alert: %w", err)
+	}
+
+	alert, err := w.store.CreateScheduleAlert(ctx, ScheduleAlert{
+		ScheduleID: schedule.ID,
+		Kind:       kind,
+		Message:    message,
+		ExpectedAt: sql.NullTime{Time: expected, Valid: !expected.IsZero()},
+	})
+	if err != nil {
+		return err
+	}
+
+	w.notify(ctx, AlertNotification{ScheduleAlert: *alert, ScheduleName: schedule.Name})
+
+	return nil
+}
+
+// resolve resolves the open alert of the kind for the schedule, if any.
+func (w *Watchdog) resolve(ctx context.Context, schedule Schedule, kind string) error {
+	alert, err := w.store.FindOpenScheduleAlert(ctx, schedule.ID, kind)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find schedule alert: %w", err)
+	}
+
+	if err := w.store.ResolveScheduleAlert(ctx, alert.ID); err != nil {
+		return fmt.Errorf("failed to resolve schedule alert: %w", err)
+	}
+
+	alert.ResolvedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	w.notify(ctx, AlertNotification{ScheduleAlert: *alert, ScheduleName: schedule.Name, Resolved: true})
+
+	return nil
+}
+
+// notify sends the notification to every notifier. Failures are logged, so one notifier can not block the others.
+func (w *Watchdog) notify(ctx context.Context, notification AlertNotification) {
+	for _, notifier := range w.notifiers {
+		if err := notifier.Notify(ctx, notification); err != nil {
+			w.log.WithError(err).Errorf("failed to send %s alert for schedule %s", notification.Kind, notification.ScheduleName)
+		}
+	}
+}
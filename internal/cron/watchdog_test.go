@@ -0,0 +1,288 @@
+package cron
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type recordingNotifier struct {
+	notifications []AlertNotification
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, notification AlertNotification) error {
+	n.notifications = append(n.notifications, notification)
+	return nil
+}
+
+func TestWatchdog(t *testing.T) {
+	ctx := context.TODO()
+
+	store, _, schedule := setupJobTest(t)
+
+	schedule.Schedule = "0 * * * *"
+	if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+		t.Fatal(err)
+	}
+
+	addJob := func(createdAt time.Time) {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := store.MarkJobScheduled(ctx, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ? WHERE id = ?", createdAt, job.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notifier := &recordingNotifier{}
+	watchdog := NewWatchdog(store, logrus.New(), 5*time.Minute, notifier)
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 18, hour, minute, 0, 0, time.UTC)
+	}
+
+	check := func(now time.Time) {
+		t.Helper()
+		if err := watchdog.Check(ctx, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check(at(10, 30))
+
+	t.Run("expected runs with completed jobs", func(t *testing.T) {
+		addJob(at(11, 0).Add(5 * time.Second))
+		check(at(11, 6))
+
+		if len(notifier.notifications) != 0 {
+			t.Fatalf("expected no alerts, got %+v", notifier.notifications)
+		}
+	})
+
+	t.Run("missed runs raise alerts once", func(t *testing.T) {
+		check(at(12, 6))
+		check(at(12, 30))
+
+		alerts, err := store.ListOpenScheduleAlerts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 2 || alerts[0].Kind != AlertMissedRun || alerts[1].Kind != AlertMissedCompletion {
+			t.Fatalf("expected missed run and completion alerts, got %+v", alerts)
+		}
+
+		if !alerts[0].ExpectedAt.Valid || !alerts[0].ExpectedAt.Time.Equal(at(12, 0)) {
+			t.Fatalf("expected the alert to be for the 12:00 run, got %v", alerts[0].ExpectedAt)
+		}
+
+		if len(notifier.notifications) != 2 {
+			t.Fatalf("expected 2 notifications, got %d", len(notifier.notifications))
+		}
+	})
+
+	t.Run("alerts resolve once the schedule runs again", func(t *testing.T) {
+		addJob(at(13, 0).Add(5 * time.Second))
+		check(at(13, 6))
+
+		alerts, err := store.ListOpenScheduleAlerts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(alerts) != 0 {
+			t.Fatalf("expected alerts to be resolved, got %+v", alerts)
+		}
+
+		if len(notifier.notifications) != 4 || !notifier.notifications[3].Resolved {
+			t.Fatalf("expected resolved notifications, got %+v", notifier.notifications)
+		}
+	})
+
+	t.Run("disabled schedules raise an alert", func(t *testing.T) {
+		schedule.Enabled = false
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		check(at(14, 6))
+
+		if _, err := store.FindOpenScheduleAlert(ctx, schedule.ID, AlertScheduleDisabled); err != nil {
+			t.Fatalf("expected a schedule disabled alert: %s", err)
+		}
+
+		if _, err := store.FindOpenScheduleAlert(ctx, schedule.ID, AlertMissedRun); err == nil {
+			t.Fatal("expected runs of a disabled schedule not to be checked")
+		}
+	})
+
+	t.Run("expected runs are checked across restarts", func(t *testing.T) {
+		schedule.Enabled = true
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		addJob(at(15, 0).Add(5 * time.Second))
+		check(at(15, 6))
+
+		// The 16:00 run is missed while the monitor restarts
+		restarted := NewWatchdog(store, logrus.New(), 5*time.Minute, notifier)
+		if err := restarted.Check(ctx, at(16, 30)); err != nil {
+			t.Fatal(err)
+		}
+
+		alert, err := store.FindOpenScheduleAlert(ctx, schedule.ID, AlertMissedRun)
+		if err != nil {
+			t.Fatalf("expected a missed run alert: %s", err)
+		}
+
+		if !alert.ExpectedAt.Time.Equal(at(16, 0)) {
+			t.Fatalf("expected the alert to be for the 16:00 run, got %v", alert.ExpectedAt)
+		}
+	})
+
+	t.Run("alert counts outlive their schedule", func(t *testing.T) {
+		before, err := store.CountScheduleAlerts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.DeleteSchedule(ctx, fmt.Sprint(schedule.ID)); err != nil {
+			t.Fatal(err)
+		}
+
+		after, err := store.CountScheduleAlerts(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if before[AlertMissedRun] != 2 || after[AlertMissedRun] != before[AlertMissedRun] {
+			t.Fatalf("expected 2 missed run alerts to be counted after deleting the schedule, got %d then %d", before[AlertMissedRun], after[AlertMissedRun])
+		}
+	})
+}
+
+func TestWatchdogRuns(t *testing.T) {
+	ctx := context.TODO()
+
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 10, 18, hour, minute, 0, 0, time.UTC)
+	}
+
+	setup := func(t *testing.T) (*Store, *Schedule, *Watchdog, *recordingNotifier) {
+		store, _, schedule := setupJobTest(t)
+
+		schedule.Schedule = "0 * * * *"
+		if err := store.UpdateSchedule(ctx, *schedule); err != nil {
+			t.Fatal(err)
+		}
+
+		notifier := &recordingNotifier{}
+		watchdog := NewWatchdog(store, logrus.New(), 5*time.Minute, notifier)
+		if err := watchdog.Check(ctx, at(10, 30)); err != nil {
+			t.Fatal(err)
+		}
+
+		return store, schedule, watchdog, notifier
+	}
+
+	createJob := func(t *testing.T, store *Store, schedule *Schedule, createdAt time.Time, scheduled bool) *Job {
+		job, err := store.CreateJob(ctx, schedule.ID, sql.NullInt64{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scheduled {
+			if err := store.MarkJobScheduled(ctx, job.ID); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET created_at = ? WHERE id = ?", createdAt, job.ID); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	t.Run("jobs triggered by hand are not runs of the schedule", func(t *testing.T) {
+		store, schedule, watchdog, _ := setup(t)
+
+		job := createJob(t, store, schedule, at(11, 0).Add(5*time.Second), false)
+		if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := watchdog.Check(ctx, at(11, 6)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.FindOpenScheduleAlert(ctx, schedule.ID, AlertMissedRun); err != nil {
+			t.Fatalf("expected a missed run alert despite the job triggered by hand: %s", err)
+		}
+	})
+
+	t.Run("queued jobs are given their timeouts from leaving the queue", func(t *testing.T) {
+		store, schedule, watchdog, notifier := setup(t)
+
+		job := createJob(t, store, schedule, at(11, 0).Add(5*time.Second), true)
+		if err := store.QueueJob(ctx, job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		// Still queued well past the schedule's timeouts
+		if err := watchdog.Check(ctx, at(11, 30)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.ExecContext(ctx, "UPDATE jobs SET status = ?, dispatched_at = ? WHERE id = ?", JobStatusRunning, at(11, 40), job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := watchdog.Check(ctx, at(11, 42)); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := store.CompleteJob(ctx, job.ID, 0, ""); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := watchdog.Check(ctx, at(11, 50)); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(notifier.notifications) != 0 {
+			t.Fatalf("expected no alerts for the queued job, got %+v", notifier.notifications)
+		}
+	})
+
+	t.Run("jobs queued past the next run raise an alert", func(t *testing.T) {
+		store, schedule, watchdog, _ := setup(t)
+
+		job := createJob(t, store, schedule, at(11, 0).Add(5*time.Second), true)
+		if err := store.QueueJob(ctx, job.ID); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := watchdog.Check(ctx, at(12, 30)); err != nil {
+			t.Fatal(err)
+		}
+
+		alert, err := store.FindOpenScheduleAlert(ctx, schedule.ID, AlertMissedCompletion)
+		if err != nil {
+			t.Fatalf("expected a missed completion alert: %s", err)
+		}
+
+		if !alert.ExpectedAt.Time.Equal(at(11, 0)) {
+			t.Fatalf("expected the alert to be for the 11:00 run, got %v", alert.ExpectedAt)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// NewCommand builds a command that runs in its own process group and is killed if its parent dies.
+func NewCommand(args []string, env []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("no command specified")
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Env = env
+
+	ensureKill(cmd)
+
+	return cmd, nil
+}
+
+// SignalGroup sends the signal to every process in the group led by pid.
+func SignalGroup(pid int, sig os.Signal) error {
+	group, err := os.FindProcess(-pid)
+	if err != nil {
+		return err
+	}
+
+	return group.Signal(sig)
+}
@@ -66,13 +66,7 @@ func (p *process) writeErr(err error) {
 }
 
 func (p *process) signal(sig os.Signal) {
-	group, err := os.FindProcess(-p.cmd.Process.Pid)
-	if err != nil {
-		p.writeErr(err)
-		return
-	}
-
-	if err = group.Signal(sig); err != nil {
+	if err := SignalGroup(p.cmd.Process.Pid, sig); err != nil {
 		p.writeErr(err)
 	}
 }
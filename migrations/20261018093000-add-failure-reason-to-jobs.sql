@@ -0,0 +1,39 @@
+
+-- +migrate Up
+CREATE TABLE jobs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    status TEXT CHECK(status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'timed_out', 'lost')) NOT NULL DEFAULT 'pending',
+    machine_id TEXT,
+    exit_code INTEGER,
+    stdout TEXT,
+    stderr TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    failure_reason TEXT,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+INSERT INTO jobs_new (id, schedule_id, status, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at)
+    SELECT id, schedule_id, status, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at FROM jobs;
+DROP TABLE jobs;
+ALTER TABLE jobs_new RENAME TO jobs;
+
+-- +migrate Down
+CREATE TABLE jobs_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    status TEXT CHECK(status IN ('pending', 'running', 'completed', 'failed', 'cancelled')) NOT NULL DEFAULT 'pending',
+    machine_id TEXT,
+    exit_code INTEGER,
+    stdout TEXT,
+    stderr TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+INSERT INTO jobs_old
+    SELECT id, schedule_id, CASE WHEN status IN ('timed_out', 'lost') THEN 'failed' ELSE status END, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at FROM jobs;
+DROP TABLE jobs;
+ALTER TABLE jobs_old RENAME TO jobs;
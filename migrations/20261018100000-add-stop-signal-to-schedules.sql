@@ -0,0 +1,10 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN stop_signal TEXT NOT NULL DEFAULT 'SIGTERM';
+ALTER TABLE schedules ADD COLUMN kill_timeout INTEGER NOT NULL DEFAULT 10;
+ALTER TABLE jobs ADD COLUMN stop_requested_at TIMESTAMP;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN stop_requested_at;
+ALTER TABLE schedules DROP COLUMN kill_timeout;
+ALTER TABLE schedules DROP COLUMN stop_signal;
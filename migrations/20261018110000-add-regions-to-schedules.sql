@@ -0,0 +1,12 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN regions TEXT NOT NULL DEFAULT '[]';
+ALTER TABLE schedules ADD COLUMN region_strategy TEXT NOT NULL DEFAULT 'ordered-failover';
+ALTER TABLE jobs ADD COLUMN region TEXT;
+ALTER TABLE jobs ADD COLUMN launch_attempts TEXT;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN launch_attempts;
+ALTER TABLE jobs DROP COLUMN region;
+ALTER TABLE schedules DROP COLUMN region_strategy;
+ALTER TABLE schedules DROP COLUMN regions;
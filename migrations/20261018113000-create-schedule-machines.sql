@@ -0,0 +1,16 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN machine_mode TEXT NOT NULL DEFAULT 'ephemeral';
+
+CREATE TABLE IF NOT EXISTS schedule_machines (
+    schedule_id INTEGER PRIMARY KEY,
+    machine_id TEXT NOT NULL,
+    config_hash TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+
+-- +migrate Down
+DROP TABLE schedule_machines;
+ALTER TABLE schedules DROP COLUMN machine_mode;
@@ -0,0 +1,10 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN pin_image BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE schedules ADD COLUMN pinned_image TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN image_digest TEXT;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN image_digest;
+ALTER TABLE schedules DROP COLUMN pinned_image;
+ALTER TABLE schedules DROP COLUMN pin_image;
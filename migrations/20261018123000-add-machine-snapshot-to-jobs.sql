@@ -0,0 +1,10 @@
+
+-- +migrate Up
+ALTER TABLE jobs ADD COLUMN config_snapshot TEXT;
+ALTER TABLE jobs ADD COLUMN machine_state TEXT;
+ALTER TABLE jobs ADD COLUMN machine_events TEXT;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN machine_events;
+ALTER TABLE jobs DROP COLUMN machine_state;
+ALTER TABLE jobs DROP COLUMN config_snapshot;
@@ -0,0 +1,6 @@
+
+-- +migrate Up
+ALTER TABLE jobs ADD COLUMN rerun_of INTEGER REFERENCES jobs(id);
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN rerun_of;
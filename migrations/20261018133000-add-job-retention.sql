@@ -0,0 +1,17 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN retention TEXT NOT NULL DEFAULT '{}';
+
+CREATE TABLE IF NOT EXISTS job_daily_summaries (
+    schedule_id INTEGER NOT NULL,
+    day TEXT NOT NULL,
+    status TEXT NOT NULL,
+    count INTEGER NOT NULL DEFAULT 0,
+    total_duration REAL NOT NULL DEFAULT 0,
+    PRIMARY KEY(schedule_id, day, status),
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+
+-- +migrate Down
+DROP TABLE job_daily_summaries;
+ALTER TABLE schedules DROP COLUMN retention;
@@ -0,0 +1,6 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN slo TEXT NOT NULL DEFAULT '{}';
+
+-- +migrate Down
+ALTER TABLE schedules DROP COLUMN slo;
@@ -0,0 +1,17 @@
+
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS schedule_alerts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    expected_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    resolved_at TIMESTAMP,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedule_alerts_open ON schedule_alerts (schedule_id, kind) WHERE resolved_at IS NULL;
+
+-- +migrate Down
+DROP TABLE schedule_alerts;
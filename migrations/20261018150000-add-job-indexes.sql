@@ -0,0 +1,8 @@
+
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_jobs_schedule_id_id ON jobs (schedule_id, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+
+-- +migrate Down
+DROP INDEX idx_jobs_status;
+DROP INDEX idx_jobs_schedule_id_id;
@@ -0,0 +1,8 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN jitter TEXT NOT NULL DEFAULT '';
+ALTER TABLE jobs ADD COLUMN jitter_delay INTEGER NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN jitter_delay;
+ALTER TABLE schedules DROP COLUMN jitter;
@@ -0,0 +1,14 @@
+
+-- +migrate Up
+ALTER TABLE schedules ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE jobs ADD COLUMN queued_at TIMESTAMP;
+ALTER TABLE jobs ADD COLUMN dispatched_at TIMESTAMP;
+-- Jobs created before the launch queue were launched as soon as they were triggered
+UPDATE jobs SET queued_at = created_at, dispatched_at = created_at;
+CREATE INDEX IF NOT EXISTS idx_jobs_status_dispatched_at ON jobs (status, dispatched_at);
+
+-- +migrate Down
+DROP INDEX idx_jobs_status_dispatched_at;
+ALTER TABLE jobs DROP COLUMN dispatched_at;
+ALTER TABLE jobs DROP COLUMN queued_at;
+ALTER TABLE schedules DROP COLUMN priority;
@@ -0,0 +1,68 @@
+
+-- +migrate Up
+CREATE TABLE jobs_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    status TEXT CHECK(status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'timed_out', 'lost', 'skipped')) NOT NULL DEFAULT 'pending',
+    machine_id TEXT,
+    exit_code INTEGER,
+    stdout TEXT,
+    stderr TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    failure_reason TEXT,
+    stop_requested_at TIMESTAMP,
+    region TEXT,
+    launch_attempts TEXT,
+    image_digest TEXT,
+    config_snapshot TEXT,
+    machine_state TEXT,
+    machine_events TEXT,
+    rerun_of INTEGER REFERENCES jobs(id),
+    jitter_delay INTEGER NOT NULL DEFAULT 0,
+    queued_at TIMESTAMP,
+    dispatched_at TIMESTAMP,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+INSERT INTO jobs_new (id, schedule_id, status, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at, failure_reason, stop_requested_at, region, launch_attempts, image_digest, config_snapshot, machine_state, machine_events, rerun_of, jitter_delay, queued_at, dispatched_at)
+    SELECT id, schedule_id, status, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at, failure_reason, stop_requested_at, region, launch_attempts, image_digest, config_snapshot, machine_state, machine_events, rerun_of, jitter_delay, queued_at, dispatched_at FROM jobs;
+DROP TABLE jobs;
+ALTER TABLE jobs_new RENAME TO jobs;
+CREATE INDEX IF NOT EXISTS idx_jobs_schedule_id_id ON jobs (schedule_id, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_dispatched_at ON jobs (status, dispatched_at);
+
+-- +migrate Down
+CREATE TABLE jobs_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_id INTEGER NOT NULL,
+    status TEXT CHECK(status IN ('pending', 'running', 'completed', 'failed', 'cancelled', 'timed_out', 'lost')) NOT NULL DEFAULT 'pending',
+    machine_id TEXT,
+    exit_code INTEGER,
+    stdout TEXT,
+    stderr TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP,
+    failure_reason TEXT,
+    stop_requested_at TIMESTAMP,
+    region TEXT,
+    launch_attempts TEXT,
+    image_digest TEXT,
+    config_snapshot TEXT,
+    machine_state TEXT,
+    machine_events TEXT,
+    rerun_of INTEGER REFERENCES jobs(id),
+    jitter_delay INTEGER NOT NULL DEFAULT 0,
+    queued_at TIMESTAMP,
+    dispatched_at TIMESTAMP,
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+INSERT INTO jobs_old (id, schedule_id, status, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at, failure_reason, stop_requested_at, region, launch_attempts, image_digest, config_snapshot, machine_state, machine_events, rerun_of, jitter_delay, queued_at, dispatched_at)
+    SELECT id, schedule_id, CASE WHEN status = 'skipped' THEN 'cancelled' ELSE status END, machine_id, exit_code, stdout, stderr, created_at, updated_at, finished_at, failure_reason, stop_requested_at, region, launch_attempts, image_digest, config_snapshot, machine_state, machine_events, rerun_of, jitter_delay, queued_at, dispatched_at FROM jobs;
+DROP TABLE jobs;
+ALTER TABLE jobs_old RENAME TO jobs;
+CREATE INDEX IF NOT EXISTS idx_jobs_schedule_id_id ON jobs (schedule_id, id);
+CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs (status);
+CREATE INDEX IF NOT EXISTS idx_jobs_status_dispatched_at ON jobs (status, dispatched_at);
@@ -0,0 +1,15 @@
+
+-- +migrate Up
+CREATE TABLE pauses (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    schedule_name TEXT NOT NULL DEFAULT '',
+    app_name TEXT NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    until TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+ALTER TABLE schedules ADD COLUMN blackout TEXT NOT NULL DEFAULT '[]';
+
+-- +migrate Down
+ALTER TABLE schedules DROP COLUMN blackout;
+DROP TABLE pauses;
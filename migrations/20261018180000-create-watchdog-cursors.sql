@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE IF NOT EXISTS watchdog_cursors (
+    schedule_id INTEGER NOT NULL,
+    kind TEXT NOT NULL,
+    checked_at TIMESTAMP NOT NULL,
+    PRIMARY KEY(schedule_id, kind),
+    FOREIGN KEY(schedule_id) REFERENCES schedules(id)
+);
+
+CREATE TABLE IF NOT EXISTS schedule_alert_counts (
+    kind TEXT PRIMARY KEY,
+    count INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO schedule_alert_counts (kind, count) SELECT kind, COUNT(*) FROM schedule_alerts GROUP BY kind;
+
+-- +migrate Down
+DROP TABLE schedule_alert_counts;
+DROP TABLE watchdog_cursors;
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE INDEX IF NOT EXISTS idx_jobs_schedule_id_created_at ON jobs (schedule_id, created_at);
+
+-- +migrate Down
+DROP INDEX idx_jobs_schedule_id_created_at;
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE jobs ADD COLUMN not_before TIMESTAMP;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN not_before;
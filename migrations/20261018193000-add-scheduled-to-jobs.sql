@@ -0,0 +1,6 @@
+-- +migrate Up
+ALTER TABLE jobs ADD COLUMN scheduled BOOLEAN NOT NULL DEFAULT false;
+UPDATE jobs SET scheduled = true WHERE not_before IS NOT NULL;
+
+-- +migrate Down
+ALTER TABLE jobs DROP COLUMN scheduled;
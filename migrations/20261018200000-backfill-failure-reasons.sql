@@ -0,0 +1,21 @@
+-- +migrate Up
+-- Jobs that failed before failure_reason was added are given the reason their stderr and exit code imply.
+-- Until then, the monitor recorded machines it could no longer find, and commands exceeding their timeout,
+-- with an exit code of -1 and a fixed message, and launch errors with an exit code of 1 and the error as
+-- stderr. Failed commands were recorded with their exit code and no stderr.
+UPDATE jobs SET failure_reason = 'machine_not_found'
+    WHERE status = 'failed' AND failure_reason IS NULL AND exit_code = -1
+    AND stderr = 'machine destroyed before we could interpret the results';
+UPDATE jobs SET failure_reason = 'command_timeout'
+    WHERE status = 'failed' AND failure_reason IS NULL AND exit_code = -1
+    AND stderr LIKE 'machine `%` exceeded the command timeout of % seconds.';
+UPDATE jobs SET failure_reason = 'launch_failed'
+    WHERE status = 'failed' AND failure_reason IS NULL AND exit_code = 1
+    AND COALESCE(stderr, '') != '';
+UPDATE jobs SET failure_reason = 'non_zero_exit'
+    WHERE status = 'failed' AND failure_reason IS NULL AND exit_code NOT IN (0, -1)
+    AND COALESCE(stderr, '') = '';
+-- Any other failed jobs, e.g. those failed by hand, stay without a reason as theirs can't be told.
+
+-- +migrate Down
+-- The backfilled reasons can't be told apart from those recorded since, so they're kept.